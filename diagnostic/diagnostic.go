@@ -0,0 +1,119 @@
+package diagnostic
+
+import (
+	"os"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/maiksch/best-lang/evaluator"
+	"github.com/maiksch/best-lang/parser"
+	"github.com/maiksch/best-lang/token"
+)
+
+type Severity int
+
+const (
+	Error Severity = iota
+	Warning
+)
+
+func (s Severity) String() string {
+	if s == Warning {
+		return "warning"
+	}
+	return "error"
+}
+
+// Diagnostic is a message about a location in the source code, ready to be
+// shown to the user.
+type Diagnostic struct {
+	Severity Severity
+	Message  string
+	Pos      token.Position
+	// Length is the number of columns to underline, starting at Pos.
+	Length int
+	// Labels mark secondary locations, like the place something was declared.
+	Labels []Label
+	// Notes are printed below the source snippet.
+	Notes []string
+}
+
+type Label struct {
+	Pos     token.Position
+	Length  int
+	Message string
+}
+
+// FromParseError converts a syntax error of the parser.
+func FromParseError(err *parser.Diagnostic) *Diagnostic {
+	return fromParser(err, Error)
+}
+
+// FromParseWarning converts a warning of the parser.
+func FromParseWarning(warning *parser.Diagnostic) *Diagnostic {
+	return fromParser(warning, Warning)
+}
+
+func fromParser(err *parser.Diagnostic, severity Severity) *Diagnostic {
+	d := &Diagnostic{
+		Severity: severity,
+		Message:  err.Message,
+		Pos:      err.Pos(),
+		Length:   tokenLength(err.Found),
+	}
+
+	for _, label := range err.Labels {
+		d.Labels = append(d.Labels, Label{Pos: label.Pos, Length: max(label.Length, 1), Message: label.Message})
+	}
+
+	return d
+}
+
+// FromRuntimeError converts an error returned by the evaluator. The call
+// stack of the error is added as notes.
+func FromRuntimeError(err *evaluator.Error) *Diagnostic {
+	d := &Diagnostic{
+		Severity: Error,
+		Message:  err.Message,
+		Pos:      err.Pos,
+		Length:   max(err.Length, 1),
+	}
+
+	for _, label := range err.Labels {
+		d.Labels = append(d.Labels, Label{Pos: label.Pos, Length: max(label.Length, 1), Message: label.Message})
+	}
+
+	if trace := strings.TrimRight(err.StackTrace(), "\n"); trace != "" {
+		for _, line := range strings.Split(trace, "\n") {
+			d.Notes = append(d.Notes, strings.TrimSpace(line))
+		}
+	}
+
+	return d
+}
+
+func tokenLength(t token.Token) int {
+	switch t.Type {
+	case token.NEWLINE, token.EOF:
+		return 1
+	case token.STRING:
+		return utf8.RuneCountInString(t.Literal) + 2
+	}
+	if len(t.Literal) == 0 {
+		return 1
+	}
+	return utf8.RuneCountInString(t.Literal)
+}
+
+// UseColor reports whether output to the given file should be colored. That
+// is the case for terminals, unless the NO_COLOR environment variable is set.
+func UseColor(f *os.File) bool {
+	if os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,163 @@
+package diagnostic
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/maiksch/best-lang/token"
+)
+
+const (
+	colorReset  = "\033[0m"
+	colorBold   = "\033[1m"
+	colorRed    = "\033[1;31m"
+	colorYellow = "\033[1;33m"
+	colorBlue   = "\033[1;34m"
+)
+
+// Renderer prints diagnostics compiler style, with the affected source lines
+// and the offending span underlined.
+type Renderer struct {
+	sources map[string][]string
+	color   bool
+}
+
+func NewRenderer(color bool) *Renderer {
+	return &Renderer{
+		sources: make(map[string][]string),
+		color:   color,
+	}
+}
+
+// AddSource makes the source code of a file available for snippets. The name
+// has to match the file name used for the lexer.
+func (r *Renderer) AddSource(filename, source string) {
+	r.sources[filename] = strings.Split(source, "\n")
+}
+
+// Render writes the diagnostic to w. It looks like this:
+//
+//	error: unknown identifier y
+//	 --> script.best:2:14
+//	  |
+//	2 |   return x + y
+//	  |              ^
+//	  = at inner(1) script.best:5:8
+func (r *Renderer) Render(w io.Writer, d *Diagnostic) {
+	severityColor := colorRed
+	if d.Severity == Warning {
+		severityColor = colorYellow
+	}
+
+	fmt.Fprintf(w, "%s%s\n", r.paint(severityColor, d.Severity.String()+":"), r.paint(colorBold, " "+d.Message))
+
+	labels := []Label{{Pos: d.Pos, Length: d.Length}}
+	labels = append(labels, d.Labels...)
+
+	// Show the snippets in source order, labels from other files last
+	order := make([]int, len(labels))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		a, b := labels[order[i]].Pos, labels[order[j]].Pos
+		if (a.Filename == d.Pos.Filename) != (b.Filename == d.Pos.Filename) {
+			return a.Filename == d.Pos.Filename
+		}
+		return a.Filename == b.Filename && a.Offset < b.Offset
+	})
+
+	width := 0
+	for _, label := range labels {
+		if n := len(strconv.Itoa(label.Pos.Line)); n > width {
+			width = n
+		}
+	}
+	gutter := strings.Repeat(" ", width)
+
+	fmt.Fprintf(w, "%s%s %s\n", gutter, r.paint(colorBlue, "-->"), d.Pos)
+
+	if _, ok := r.line(d.Pos); ok {
+		fmt.Fprintf(w, "%s %s\n", gutter, r.paint(colorBlue, "|"))
+	}
+
+	var previous token.Position
+	for _, i := range order {
+		label := labels[i]
+
+		line, ok := r.line(label.Pos)
+		if !ok {
+			continue
+		}
+
+		// Snippets of other files get their own header, so their line
+		// numbers are not mistaken for lines of the main file
+		if label.Pos.Filename != d.Pos.Filename && label.Pos.Filename != previous.Filename {
+			fmt.Fprintf(w, "%s%s %s\n", gutter, r.paint(colorBlue, "-->"), label.Pos)
+			fmt.Fprintf(w, "%s %s\n", gutter, r.paint(colorBlue, "|"))
+		}
+
+		if label.Pos.Filename != previous.Filename || label.Pos.Line != previous.Line {
+			fmt.Fprintf(w, "%s %s %s\n", r.paint(colorBlue, fmt.Sprintf("%*d", width, label.Pos.Line)), r.paint(colorBlue, "|"), line)
+			previous = label.Pos
+		}
+
+		marker, markerColor := "-", colorBlue
+		if i == 0 {
+			marker, markerColor = "^", severityColor
+		}
+
+		length := label.Length
+		if length < 1 {
+			length = 1
+		}
+
+		underline := strings.Repeat(marker, length)
+		if label.Message != "" {
+			underline += " " + label.Message
+		}
+
+		fmt.Fprintf(w, "%s %s %s%s\n", gutter, r.paint(colorBlue, "|"), indentation(line, label.Pos.Column), r.paint(markerColor, underline))
+	}
+
+	for _, note := range d.Notes {
+		fmt.Fprintf(w, "%s %s %s\n", gutter, r.paint(colorBlue, "="), note)
+	}
+}
+
+func (r *Renderer) line(pos token.Position) (string, bool) {
+	lines, ok := r.sources[pos.Filename]
+	if !ok || pos.Line < 1 || pos.Line > len(lines) {
+		return "", false
+	}
+	return strings.TrimRight(lines[pos.Line-1], "\r"), true
+}
+
+func (r *Renderer) paint(color, s string) string {
+	if !r.color {
+		return s
+	}
+	return color + s + colorReset
+}
+
+// indentation returns the whitespace needed to put a marker below the given
+// column of line. Tabs are kept, so the marker lines up in any terminal.
+func indentation(line string, column int) string {
+	var out strings.Builder
+	i := 0
+	for _, char := range line {
+		if i >= column-1 {
+			break
+		}
+		if char == '\t' {
+			out.WriteByte('\t')
+		} else {
+			out.WriteByte(' ')
+		}
+		i++
+	}
+	return out.String()
+}
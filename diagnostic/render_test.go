@@ -0,0 +1,173 @@
+package diagnostic_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/maiksch/best-lang/diagnostic"
+	"github.com/maiksch/best-lang/evaluator"
+	"github.com/maiksch/best-lang/lexer"
+	"github.com/maiksch/best-lang/parser"
+	"github.com/maiksch/best-lang/token"
+)
+
+func TestRenderParseError(t *testing.T) {
+	input := `var x = 1
+if x > 0 {
+	x + (1 * 2
+}`
+	expect := `error: invalid syntax. Opened ( is missing closing )
+ --> test.best:3:12
+  |
+3 | 	x + (1 * 2
+  | 	    - ( opened here
+  | 	          ^
+`
+
+	p := parser.New(lexer.NewFile("test.best", input))
+	p.ParseProgram()
+
+	errors := p.Errors()
+	if len(errors) == 0 {
+		t.Fatalf("expected syntax errors")
+	}
+
+	expectRendered(t, input, diagnostic.FromParseError(errors[0]), expect)
+}
+
+func TestRenderRuntimeError(t *testing.T) {
+	input := `var add = fn(a, b) {
+	return a + b
+}
+add(1, true)`
+	expect := `error: operator type mismatch. INTEGER + BOOLEAN
+ --> test.best:2:11
+  |
+2 | 	return a + b
+  | 	         ^
+  = at add(1, true) test.best:4:1
+`
+
+	p := parser.New(lexer.NewFile("test.best", input))
+	program := p.ParseProgram()
+	result := evaluator.Eval(program, evaluator.NewEnvrionment())
+
+	err, ok := result.(*evaluator.Error)
+	if !ok {
+		t.Fatalf("expected error value. got %T", result)
+	}
+
+	expectRendered(t, input, diagnostic.FromRuntimeError(err), expect)
+}
+
+func TestRenderCallError(t *testing.T) {
+	input := `var x = fn(a) { a }
+x(1, 2)`
+	expect := `error: wrong number of arguments for x. expected 1, got 2
+ --> test.best:2:1
+  |
+1 | var x = fn(a) { a }
+  |         -- declared here
+2 | x(1, 2)
+  | ^^^^^^^
+`
+
+	p := parser.New(lexer.NewFile("test.best", input))
+	program := p.ParseProgram()
+	result := evaluator.Eval(program, evaluator.NewEnvrionment())
+
+	err, ok := result.(*evaluator.Error)
+	if !ok {
+		t.Fatalf("expected error value. got %T", result)
+	}
+
+	expectRendered(t, input, diagnostic.FromRuntimeError(err), expect)
+}
+
+func TestRenderUnicode(t *testing.T) {
+	input := `größe := "äöü" + grün`
+	expect := `error: unknown identifier grün
+ --> test.best:1:18
+  |
+1 | größe := "äöü" + grün
+  |                  ^^^^
+`
+
+	p := parser.New(lexer.NewFile("test.best", input))
+	program := p.ParseProgram()
+	result := evaluator.Eval(program, evaluator.NewEnvrionment())
+
+	err, ok := result.(*evaluator.Error)
+	if !ok {
+		t.Fatalf("expected error value. got %T", result)
+	}
+
+	expectRendered(t, input, diagnostic.FromRuntimeError(err), expect)
+}
+
+func TestRenderLabelInOtherFile(t *testing.T) {
+	d := &diagnostic.Diagnostic{
+		Message: "x is already declared",
+		Pos:     token.Position{Filename: "<input 3>", Line: 1, Column: 1},
+		Length:  1,
+		Labels: []diagnostic.Label{{
+			Pos:     token.Position{Filename: "<input 1>", Line: 1, Column: 1},
+			Length:  1,
+			Message: "previously declared here",
+		}},
+	}
+	expect := `error: x is already declared
+ --> <input 3>:1:1
+  |
+1 | x := 2
+  | ^
+ --> <input 1>:1:1
+  |
+1 | x := 1
+  | - previously declared here
+`
+
+	renderer := diagnostic.NewRenderer(false)
+	renderer.AddSource("<input 1>", "x := 1")
+	renderer.AddSource("<input 3>", "x := 2")
+
+	var out bytes.Buffer
+	renderer.Render(&out, d)
+
+	if out.String() != expect {
+		t.Fatalf("wrong output\nexpected:\n%s\ngot:\n%s", expect, out.String())
+	}
+}
+
+func TestRenderWithoutSource(t *testing.T) {
+	d := &diagnostic.Diagnostic{Message: "something went wrong"}
+	expect := `error: something went wrong
+ --> -
+`
+
+	expectRendered(t, "", d, expect)
+}
+
+func TestRenderColor(t *testing.T) {
+	d := &diagnostic.Diagnostic{Severity: diagnostic.Warning, Message: "careful"}
+	expect := "\033[1;33mwarning:\033[0m\033[1m careful\033[0m\n \033[1;34m-->\033[0m -\n"
+
+	var out bytes.Buffer
+	diagnostic.NewRenderer(true).Render(&out, d)
+
+	if out.String() != expect {
+		t.Fatalf("wrong colored output\n\texpected: %q\n\tgot:      %q", expect, out.String())
+	}
+}
+
+func expectRendered(t *testing.T, source string, d *diagnostic.Diagnostic, expect string) {
+	renderer := diagnostic.NewRenderer(false)
+	renderer.AddSource("test.best", source)
+
+	var out bytes.Buffer
+	renderer.Render(&out, d)
+
+	if out.String() != expect {
+		t.Fatalf("wrong output\nexpected:\n%s\ngot:\n%s", expect, out.String())
+	}
+}
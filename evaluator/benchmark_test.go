@@ -0,0 +1,62 @@
+package evaluator_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/maiksch/best-lang/evaluator"
+	"github.com/maiksch/best-lang/lexer"
+	"github.com/maiksch/best-lang/parser"
+)
+
+const fib = `fn fib(n) {
+	if n < 2 { return n }
+	fib(n - 1) + fib(n - 2)
+}
+`
+
+func BenchmarkFib(b *testing.B) {
+	benchmarkProgram(b, fib+"fib(20)")
+}
+
+// Calls should not get slower the more variables are declared around them.
+func BenchmarkFibManyGlobals(b *testing.B) {
+	var globals strings.Builder
+	for i := 0; i < 200; i++ {
+		fmt.Fprintf(&globals, "var global_%c%c = %d\n", 'a'+i/26, 'a'+i%26, i)
+	}
+	benchmarkProgram(b, globals.String()+fib+"fib(20)")
+}
+
+func BenchmarkClosureCounter(b *testing.B) {
+	benchmarkProgram(b, `var counter = fn() {
+		count := 0
+		fn() { count += 1 }
+	}
+	var next = counter()
+	fn run(n) {
+		if n > 0 {
+			next()
+			run(n - 1)
+		}
+	}
+	run(5000)`)
+}
+
+func benchmarkProgram(b *testing.B, input string) {
+	p := parser.New(lexer.New(input))
+	program := p.ParseProgram()
+	if len(p.Errors()) > 0 {
+		b.Fatalf("parse errors: %v", p.Errors())
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		result := evaluator.Eval(program, evaluator.NewEnvrionment())
+		if err, ok := result.(*evaluator.Error); ok {
+			b.Fatal(err.Inspect())
+		}
+	}
+}
@@ -0,0 +1,175 @@
+package evaluator
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/maiksch/best-lang/parser"
+)
+
+var builtins = map[string]*Builtin{
+	"exit":   {Name: "exit", Fn: builtinExit},
+	"len":    {Name: "len", Fn: builtinLen},
+	"keys":   {Name: "keys", Fn: builtinKeys},
+	"values": {Name: "values", Fn: builtinValues},
+	"has":    {Name: "has", Fn: builtinHas},
+	"int":    {Name: "int", Fn: builtinInt},
+	"float":  {Name: "float", Fn: builtinFloat},
+}
+
+// expectArguments returns an error if the builtin was not called with the
+// expected number of arguments.
+func expectArguments(call *parser.FunctionCall, name string, args []Object, count int) *Error {
+	if len(args) != count {
+		return newError(call, "wrong number of arguments for %s. expected %d, got %d", name, count, len(args))
+	}
+	return nil
+}
+
+// exitSignal is raised as a panic by the exit builtin. It unwinds the whole
+// evaluation and is turned into an *Exit by Eval.
+type exitSignal struct {
+	code int64
+}
+
+func builtinExit(call *parser.FunctionCall, args ...Object) Object {
+	if len(args) > 1 {
+		return newError(call, "wrong number of arguments for exit. expected 0 or 1, got %d", len(args))
+	}
+
+	var code int64
+	if len(args) == 1 {
+		integer, ok := args[0].(*Integer)
+		if !ok {
+			return newError(call.Arguments[0], "exit code must be INTEGER. got %s", typeName(args[0]))
+		}
+		if integer.Value < 0 || integer.Value > 255 {
+			return newError(call.Arguments[0], "exit code must be between 0 and 255. got %d", integer.Value)
+		}
+		code = integer.Value
+	}
+
+	panic(exitSignal{code: code})
+}
+
+func builtinLen(call *parser.FunctionCall, args ...Object) Object {
+	if err := expectArguments(call, "len", args, 1); err != nil {
+		return err
+	}
+
+	switch arg := args[0].(type) {
+	case *Array:
+		return &Integer{Value: int64(len(arg.Elements))}
+	case *String:
+		return &Integer{Value: int64(utf8.RuneCountInString(arg.Value))}
+	case *Hash:
+		return &Integer{Value: int64(len(arg.Order))}
+	default:
+		return newError(call.Arguments[0], "argument to len not supported. got %s", typeName(arg))
+	}
+}
+
+func builtinKeys(call *parser.FunctionCall, args ...Object) Object {
+	if err := expectArguments(call, "keys", args, 1); err != nil {
+		return err
+	}
+
+	hash, ok := args[0].(*Hash)
+	if !ok {
+		return newError(call.Arguments[0], "argument to keys must be HASH. got %s", typeName(args[0]))
+	}
+
+	keys := make([]Object, len(hash.Order))
+	for i, key := range hash.Order {
+		keys[i] = hash.Pairs[key].Key
+	}
+
+	return &Array{Elements: keys}
+}
+
+func builtinValues(call *parser.FunctionCall, args ...Object) Object {
+	if err := expectArguments(call, "values", args, 1); err != nil {
+		return err
+	}
+
+	hash, ok := args[0].(*Hash)
+	if !ok {
+		return newError(call.Arguments[0], "argument to values must be HASH. got %s", typeName(args[0]))
+	}
+
+	values := make([]Object, len(hash.Order))
+	for i, key := range hash.Order {
+		values[i] = hash.Pairs[key].Value
+	}
+
+	return &Array{Elements: values}
+}
+
+func builtinHas(call *parser.FunctionCall, args ...Object) Object {
+	if err := expectArguments(call, "has", args, 2); err != nil {
+		return err
+	}
+
+	hash, ok := args[0].(*Hash)
+	if !ok {
+		return newError(call.Arguments[0], "first argument to has must be HASH. got %s", typeName(args[0]))
+	}
+
+	key, ok := args[1].(Hashable)
+	if !ok {
+		return newError(call.Arguments[1], "unusable as hash key: %s", typeName(args[1]))
+	}
+
+	_, found := hash.Get(key)
+	return toBooleanObject(found)
+}
+
+// builtinInt converts numbers and strings to integers. Floats are truncated
+// towards zero.
+func builtinInt(call *parser.FunctionCall, args ...Object) Object {
+	if err := expectArguments(call, "int", args, 1); err != nil {
+		return err
+	}
+
+	switch arg := args[0].(type) {
+	case *Integer:
+		return arg
+	case *Float:
+		if math.IsNaN(arg.Value) || arg.Value >= math.MaxInt64 || arg.Value < math.MinInt64 {
+			return newError(call.Arguments[0], "cannot convert %s to INTEGER", arg.Inspect())
+		}
+		return &Integer{Value: int64(arg.Value)}
+	case *String:
+		value, err := strconv.ParseInt(strings.TrimSpace(arg.Value), 10, 64)
+		if err != nil {
+			return newError(call.Arguments[0], "cannot convert %q to INTEGER", arg.Value)
+		}
+		return &Integer{Value: value}
+	default:
+		return newError(call.Arguments[0], "argument to int not supported. got %s", typeName(arg))
+	}
+}
+
+// builtinFloat converts numbers and strings to floats.
+func builtinFloat(call *parser.FunctionCall, args ...Object) Object {
+	if err := expectArguments(call, "float", args, 1); err != nil {
+		return err
+	}
+
+	switch arg := args[0].(type) {
+	case *Integer:
+		return &Float{Value: float64(arg.Value)}
+	case *Float:
+		return arg
+	case *String:
+		value, err := strconv.ParseFloat(strings.TrimSpace(arg.Value), 64)
+		if err != nil {
+			return newError(call.Arguments[0], "cannot convert %q to FLOAT", arg.Value)
+		}
+		return &Float{Value: value}
+	default:
+		return newError(call.Arguments[0], "argument to float not supported. got %s", typeName(arg))
+	}
+}
@@ -1,36 +1,96 @@
 package evaluator
 
-import "github.com/maiksch/best-lang/parser"
+import (
+	"unicode/utf8"
 
-type Environment struct {
-	identifiers map[string]Object
+	"github.com/maiksch/best-lang/parser"
+	"github.com/maiksch/best-lang/token"
+)
+
+// binding holds the value of a variable and where it was declared.
+type binding struct {
+	value Object
+	pos   token.Position
 }
 
-func CloneEnvironment(outer *Environment) *Environment {
-	env := NewEnvrionment()
+// Environment is a scope of variables. Scopes are chained: names that are not
+// declared in a scope are looked up in its outer scope. Functions keep a
+// reference to the scope they were created in, so closures see and change the
+// variables of that scope instead of a copy.
+type Environment struct {
+	store map[string]*binding
+	outer *Environment
+	// depth is the number of function calls the environment is nested in.
+	depth int
+}
 
-	for k, v := range outer.identifiers {
-		env.identifiers[k] = v
-	}
+func NewEnvrionment() *Environment {
+	return &Environment{store: make(map[string]*binding)}
+}
 
+// NewEnclosedEnvironment creates a scope nested in outer.
+func NewEnclosedEnvironment(outer *Environment) *Environment {
+	env := NewEnvrionment()
+	env.outer = outer
+	env.depth = outer.depth
 	return env
 }
 
-func NewEnvrionment() *Environment {
-	return &Environment{
-		identifiers: make(map[string]Object),
+// lookup finds the binding of a name in the scope chain.
+func (e *Environment) lookup(name string) (*binding, bool) {
+	for env := e; env != nil; env = env.outer {
+		if b, ok := env.store[name]; ok {
+			return b, true
+		}
 	}
+	return nil, false
 }
 
 func (e *Environment) get(identifier *parser.Identifier) Object {
-	val, ok := e.identifiers[identifier.Value]
+	b, ok := e.lookup(identifier.Value)
 	if !ok {
-		return newError("unknown identifier %s", identifier.Value)
+		if builtin, ok := builtins[identifier.Value]; ok {
+			return builtin
+		}
+		return newError(identifier, "unknown identifier %s", identifier.Value)
 	}
-	return val
+	return b.value
 }
 
-func (e *Environment) set(identifier *parser.Identifier, value Object) Object {
-	e.identifiers[identifier.Value] = value
+// declare binds a new name. Names can only be declared once per scope, but
+// may shadow names of outer scopes.
+func (e *Environment) declare(identifier *parser.Identifier, value Object) Object {
+	if b, ok := e.store[identifier.Value]; ok {
+		err := newError(identifier, "%s is already declared", identifier.Value)
+		err.Labels = append(err.Labels, Label{
+			Pos:     b.pos,
+			Length:  utf8.RuneCountInString(identifier.Value),
+			Message: "previously declared here",
+		})
+		return err
+	}
+
+	e.store[identifier.Value] = &binding{value: value, pos: identifier.Pos()}
+	return value
+}
+
+// assign changes the value of an existing variable in the nearest scope that
+// declares it.
+func (e *Environment) assign(identifier *parser.Identifier, value Object) Object {
+	b, ok := e.lookup(identifier.Value)
+	if !ok {
+		return undeclaredAssignment(identifier)
+	}
+
+	b.value = value
 	return value
 }
+
+// undeclaredAssignment is the error for assigning to a name that is not a
+// declared variable.
+func undeclaredAssignment(identifier *parser.Identifier) *Error {
+	if _, ok := builtins[identifier.Value]; ok {
+		return newError(identifier, "cannot assign to builtin %s", identifier.Value)
+	}
+	return newError(identifier, "cannot assign to undeclared variable %s", identifier.Value)
+}
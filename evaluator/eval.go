@@ -2,18 +2,44 @@ package evaluator
 
 import (
 	"fmt"
-	"log"
+	"math"
+	"strings"
+	"unicode/utf8"
 
 	"github.com/maiksch/best-lang/parser"
 )
 
-func Eval(node parser.Node, env *Environment) Object {
+// maxCallDepth limits the nesting of function calls. Go can't recover from
+// running out of stack, so infinite recursion has to be stopped before that.
+const maxCallDepth = 10000
+
+// Eval evaluates the node in the given environment. It never panics, problems
+// in the evaluator itself are returned as an *Error as well. If the program
+// calls the exit builtin, an *Exit is returned.
+func Eval(node parser.Node, env *Environment) (result Object) {
+	defer func() {
+		if r := recover(); r != nil {
+			if exit, ok := r.(exitSignal); ok {
+				result = &Exit{Code: exit.code}
+				return
+			}
+			result = &Error{Message: fmt.Sprintf("internal error: %v", r)}
+		}
+	}()
+
+	return eval(node, env)
+}
+
+func eval(node parser.Node, env *Environment) Object {
 	switch node := node.(type) {
+	case nil:
+		return &Error{Message: "missing expression"}
+
 	case *parser.Program:
 		return evalProgram(node, env)
 
 	case *parser.ExpressionStatement:
-		return Eval(node.Value, env)
+		return eval(node.Value, env)
 
 	case *parser.BlockStatement:
 		return evalBlockStatement(node, env)
@@ -21,8 +47,30 @@ func Eval(node parser.Node, env *Environment) Object {
 	case *parser.DeclareStatement:
 		return evalDeclareStatement(node, env)
 
+	case *parser.AssignStatement:
+		return evalAssignStatement(node, env)
+
+	case *parser.WhileStatement:
+		return evalWhileStatement(node, env)
+
+	case *parser.ForStatement:
+		return evalForStatement(node, env)
+
+	case *parser.BreakStatement:
+		return BREAK_OBJ
+
+	case *parser.ContinueStatement:
+		return CONTINUE_OBJ
+
+	case *parser.FunctionDeclaration:
+		// The function was bound already when its block was entered
+		return env.get(node.Name)
+
+	case *parser.EnumDeclaration:
+		return evalEnumDeclaration(node, env)
+
 	case *parser.ReturnStatement:
-		val := Eval(node.Expression, env)
+		val := eval(node.Expression, env)
 		if isError(val) {
 			return val
 		}
@@ -33,11 +81,28 @@ func Eval(node parser.Node, env *Environment) Object {
 			Parameters: node.Parameters,
 			Body:       node.Body,
 			Env:        env,
+			Pos:        node.Pos(),
 		}
 
 	case *parser.FunctionCall:
 		return evalFunctionCall(node, env)
 
+	case *parser.ArrayLiteral:
+		elements, err := evalExpressions(node.Elements, env)
+		if err != nil {
+			return err
+		}
+		return &Array{Elements: elements}
+
+	case *parser.HashLiteral:
+		return evalHashLiteral(node, env)
+
+	case *parser.IndexExpression:
+		return evalIndexExpression(node, env)
+
+	case *parser.MemberExpression:
+		return evalMemberExpression(node, env)
+
 	case *parser.PrefixExpression:
 		return evalPrefixExpression(node, env)
 
@@ -53,79 +118,280 @@ func Eval(node parser.Node, env *Environment) Object {
 	case *parser.IntegerLiteral:
 		return &Integer{Value: node.Value}
 
+	case *parser.FloatLiteral:
+		return &Float{Value: node.Value}
+
 	case *parser.StringLiteral:
 		return &String{Value: node.Value}
 
+	case *parser.InterpolatedString:
+		return evalInterpolatedString(node, env)
+
 	case *parser.BooleanLiteral:
 		return toBooleanObject(node.Value)
 
 	default:
-		log.Panicf("eval for %T not implemented", node)
-		return nil
+		return newError(node, "eval for %T not implemented", node)
 	}
 }
 
 func evalFunctionCall(call *parser.FunctionCall, env *Environment) Object {
-	fn := Eval(call.Function, env)
+	fn := eval(call.Function, env)
 	if isError(fn) {
 		return fn
 	}
 
 	if fn, ok := fn.(*Function); ok {
-		closure := CloneEnvironment(fn.Env)
+		if len(call.Arguments) != len(fn.Parameters) {
+			err := newError(call, "wrong number of arguments for %s. expected %d, got %d",
+				fn.displayName(), len(fn.Parameters), len(call.Arguments))
+			err.Labels = append(err.Labels, Label{Pos: fn.Pos, Length: len("fn"), Message: "declared here"})
+			return err
+		}
 
+		if env.depth >= maxCallDepth {
+			return newError(call, "maximum call depth of %d exceeded", maxCallDepth)
+		}
+
+		closure := NewEnclosedEnvironment(fn.Env)
+		closure.depth = env.depth + 1
+
+		args := make([]Object, 0, len(call.Arguments))
 		for i, arg := range call.Arguments {
-			val := Eval(arg, env)
+			val := eval(arg, env)
 			if isError(val) {
 				return val
 			}
-			closure.set(fn.Parameters[i], val)
+			if declared := closure.declare(fn.Parameters[i], val); isError(declared) {
+				return declared
+			}
+			args = append(args, val)
 		}
 
-		result := Eval(fn.Body, closure)
+		result := eval(fn.Body, closure)
 
 		if returnValue, ok := result.(*ReturnValue); ok {
 			result = returnValue.Value
 		}
 
+		if err, ok := result.(*Error); ok {
+			err.Stack = append(err.Stack, StackFrame{
+				Function:  fn.displayName(),
+				Pos:       call.Pos(),
+				Arguments: args,
+			})
+		}
+
 		return result
 	}
 
-	return newError("unsuported function expression. %T", call.Function)
+	if builtin, ok := fn.(*Builtin); ok {
+		args, err := evalExpressions(call.Arguments, env)
+		if err != nil {
+			return err
+		}
+
+		return builtin.Fn(call, args...)
+	}
+
+	return newError(call.Function, "unsuported function expression. %T", call.Function)
+}
+
+func evalIndexExpression(expr *parser.IndexExpression, env *Environment) Object {
+	left := eval(expr.Left, env)
+	if isError(left) {
+		return left
+	}
+	index := eval(expr.Index, env)
+	if isError(index) {
+		return index
+	}
+
+	switch {
+	case left.Type() == ARRAY && index.Type() == INTEGER:
+		elements := left.(*Array).Elements
+		i := index.(*Integer).Value
+		if i < 0 || i >= int64(len(elements)) {
+			return newError(expr.Index, "index %d out of range for array of length %d", i, len(elements))
+		}
+		return elements[i]
+
+	case left.Type() == HASH:
+		key, ok := index.(Hashable)
+		if !ok {
+			return newError(expr.Index, "unusable as hash key: %s", typeName(index))
+		}
+		value, ok := left.(*Hash).Get(key)
+		if !ok {
+			return newError(expr.Index, "key %s not found", inspectQuoted(index))
+		}
+		return value
+
+	case left.Type() == ENUM && index.Type() == STRING:
+		value := left.(*EnumValue)
+		field, ok := value.Field(index.(*String).Value)
+		if !ok {
+			return newError(expr.Index, "%s has no field %s", value.variantName(), index.Inspect())
+		}
+		return field
+
+	default:
+		return newError(expr, "index operator not supported. %s[%s]", typeName(left), typeName(index))
+	}
+}
+
+// evalMemberExpression looks up the variant of an enum, like Shape.Circle, or
+// a field of an enum value, like circle.r.
+func evalMemberExpression(expr *parser.MemberExpression, env *Environment) Object {
+	left := eval(expr.Left, env)
+	if isError(left) {
+		return left
+	}
+
+	switch left := left.(type) {
+	case *Enum:
+		variant, ok := left.Variants[expr.Member.Value]
+		if !ok {
+			return newError(expr.Member, "%s has no variant %s", left.Declaration.Name.Value, expr.Member.Value)
+		}
+		return variant
+
+	case *EnumValue:
+		field, ok := left.Field(expr.Member.Value)
+		if !ok {
+			return newError(expr.Member, "%s has no field %s", left.variantName(), expr.Member.Value)
+		}
+		return field
+
+	default:
+		return newError(expr, "member access not supported. %s.%s", typeName(left), expr.Member.Value)
+	}
+}
+
+func evalHashLiteral(node *parser.HashLiteral, env *Environment) Object {
+	hash := NewHash()
+
+	for i, keyNode := range node.Keys {
+		key := eval(keyNode, env)
+		if isError(key) {
+			return key
+		}
+
+		hashKey, ok := key.(Hashable)
+		if !ok {
+			return newError(keyNode, "unusable as hash key: %s", typeName(key))
+		}
+
+		value := eval(node.Values[i], env)
+		if isError(value) {
+			return value
+		}
+
+		hash.Set(hashKey, value)
+	}
+
+	return hash
+}
+
+// evalInterpolatedString joins the parts of a string, printing values like Inspect.
+func evalInterpolatedString(node *parser.InterpolatedString, env *Environment) Object {
+	var out strings.Builder
+
+	for _, part := range node.Parts {
+		value := eval(part, env)
+		if isError(value) {
+			return value
+		}
+		out.WriteString(value.Inspect())
+	}
+
+	return &String{Value: out.String()}
+}
+
+// evalExpressions evaluates the expressions in order. It stops at the first
+// error and returns it.
+func evalExpressions(exprs []parser.Expression, env *Environment) ([]Object, Object) {
+	result := make([]Object, 0, len(exprs))
+
+	for _, expr := range exprs {
+		val := eval(expr, env)
+		if isError(val) {
+			return nil, val
+		}
+		result = append(result, val)
+	}
+
+	return result, nil
 }
 
 func evalIfExpression(expr *parser.IfExpression, env *Environment) Object {
-	condition := Eval(expr.Condition, env)
+	condition := eval(expr.Condition, env)
 	if isError(condition) {
 		return condition
 	}
 
+	// Each branch is a scope of its own
 	if condition == TRUE {
-		return Eval(expr.Consequence, env)
+		return eval(expr.Consequence, NewEnclosedEnvironment(env))
 	}
 
 	if expr.Otherwise != nil {
-		return Eval(expr.Otherwise, env)
+		return eval(expr.Otherwise, NewEnclosedEnvironment(env))
 	}
 
 	return &Nothing{}
 }
 
 func evalInfixExpression(expr *parser.InfixExpression, env *Environment) Object {
-	left := Eval(expr.Left, env)
+	if expr.Operator == "&&" || expr.Operator == "||" {
+		return evalLogicalExpression(expr, env)
+	}
+
+	left := eval(expr.Left, env)
 	if isError(left) {
 		return left
 	}
-	right := Eval(expr.Right, env)
+	right := eval(expr.Right, env)
 	if isError(right) {
 		return right
 	}
 
+	return evalInfixOperator(expr, expr.Operator, left, right)
+}
+
+// evalLogicalExpression evaluates && and ||. The right operand is only
+// evaluated if the left one doesn't decide the result already.
+func evalLogicalExpression(expr *parser.InfixExpression, env *Environment) Object {
+	left := eval(expr.Left, env)
+	if isError(left) {
+		return left
+	}
+	if left.Type() != BOOLEAN {
+		return newError(expr.Left, "invalid operand for %s. expected BOOLEAN, got %s", expr.Operator, typeName(left))
+	}
+
+	if expr.Operator == "&&" && left == FALSE || expr.Operator == "||" && left == TRUE {
+		return left
+	}
+
+	right := eval(expr.Right, env)
+	if isError(right) {
+		return right
+	}
+	if right.Type() != BOOLEAN {
+		return newError(expr.Right, "invalid operand for %s. expected BOOLEAN, got %s", expr.Operator, typeName(right))
+	}
+
+	return right
+}
+
+// evalInfixOperator applies a binary operator. Errors point at node.
+func evalInfixOperator(node parser.Node, operator string, left, right Object) Object {
 	if left.Type() == INTEGER && right.Type() == INTEGER {
 		l := left.(*Integer).Value
 		r := right.(*Integer).Value
 
-		switch expr.Operator {
+		switch operator {
 		case "==":
 			return toBooleanObject(l == r)
 
@@ -138,6 +404,12 @@ func evalInfixExpression(expr *parser.InfixExpression, env *Environment) Object
 		case "<":
 			return toBooleanObject(l < r)
 
+		case ">=":
+			return toBooleanObject(l >= r)
+
+		case "<=":
+			return toBooleanObject(l <= r)
+
 		case "+":
 			return &Integer{Value: l + r}
 
@@ -145,18 +417,39 @@ func evalInfixExpression(expr *parser.InfixExpression, env *Environment) Object
 			return &Integer{Value: l - r}
 
 		case "/":
+			if r == 0 {
+				return newError(node, "division by zero")
+			}
 			return &Integer{Value: l / r}
 
 		case "*":
 			return &Integer{Value: l * r}
+
+		case "%":
+			// The remainder has the sign of the left operand, like in Go
+			// and C: -7 % 3 is -1 and 7 % -3 is 1.
+			if r == 0 {
+				return newError(node, "division by zero")
+			}
+			return &Integer{Value: l % r}
+
+		case "**":
+			if r < 0 {
+				return newError(node, "negative exponent %d. integers can only be raised to non-negative powers", r)
+			}
+			return &Integer{Value: power(l, r)}
 		}
 	}
 
+	if l, r, ok := floatOperands(left, right); ok {
+		return evalFloatOperator(node, operator, l, r)
+	}
+
 	if left.Type() == STRING && right.Type() == STRING {
 		l := left.(*String).Value
 		r := right.(*String).Value
 
-		switch expr.Operator {
+		switch operator {
 		case "==":
 			return toBooleanObject(l == r)
 
@@ -169,7 +462,7 @@ func evalInfixExpression(expr *parser.InfixExpression, env *Environment) Object
 	}
 
 	if left.Type() == BOOLEAN && right.Type() == BOOLEAN {
-		switch expr.Operator {
+		switch operator {
 		case "==":
 			return toBooleanObject(left == right)
 
@@ -178,51 +471,324 @@ func evalInfixExpression(expr *parser.InfixExpression, env *Environment) Object
 		}
 	}
 
-	if expr.Operator == "==" {
+	if left.Type() == ENUM && right.Type() == ENUM {
+		switch operator {
+		case "==":
+			return toBooleanObject(enumsEqual(node, left.(*EnumValue), right.(*EnumValue)))
+
+		case "!=":
+			return toBooleanObject(!enumsEqual(node, left.(*EnumValue), right.(*EnumValue)))
+		}
+	}
+
+	if operator == "==" {
 		return FALSE
 	}
 
-	if expr.Operator == "!=" {
+	if operator == "!=" {
 		return TRUE
 	}
 
-	return newError("operator type mismatch. %s %s %s", left.Type(), expr.Operator, right.Type())
+	return newError(node, "operator type mismatch. %s %s %s", typeName(left), operator, typeName(right))
+}
+
+// enumsEqual reports whether two enum values are the same variant of the same
+// enum declaration and carry equal payloads. Payloads that == can't compare,
+// like arrays and functions, are only equal to themselves.
+func enumsEqual(node parser.Node, left, right *EnumValue) bool {
+	if left.Variant != right.Variant {
+		return false
+	}
+	for i := range left.Payload {
+		if left.Payload[i] == right.Payload[i] {
+			continue
+		}
+		if evalInfixOperator(node, "==", left.Payload[i], right.Payload[i]) != TRUE {
+			return false
+		}
+	}
+	return true
+}
+
+// floatOperands converts the operands of an infix operator to floats. This
+// happens if one operand is a float and the other one is a number, so that
+// 1 + 0.5 is 1.5.
+func floatOperands(left, right Object) (float64, float64, bool) {
+	l, leftOk := toFloat(left)
+	r, rightOk := toFloat(right)
+	if !leftOk || !rightOk || left.Type() != FLOAT && right.Type() != FLOAT {
+		return 0, 0, false
+	}
+	return l, r, true
+}
+
+func toFloat(obj Object) (float64, bool) {
+	switch obj := obj.(type) {
+	case *Integer:
+		return float64(obj.Value), true
+	case *Float:
+		return obj.Value, true
+	}
+	return 0, false
+}
+
+func evalFloatOperator(node parser.Node, operator string, l, r float64) Object {
+	switch operator {
+	case "==":
+		return toBooleanObject(l == r)
+
+	case "!=":
+		return toBooleanObject(l != r)
+
+	case ">":
+		return toBooleanObject(l > r)
+
+	case "<":
+		return toBooleanObject(l < r)
+
+	case ">=":
+		return toBooleanObject(l >= r)
+
+	case "<=":
+		return toBooleanObject(l <= r)
+
+	case "+":
+		return &Float{Value: l + r}
+
+	case "-":
+		return &Float{Value: l - r}
+
+	case "*":
+		return &Float{Value: l * r}
+
+	case "/":
+		if r == 0 {
+			return newError(node, "division by zero")
+		}
+		return &Float{Value: l / r}
+
+	case "%":
+		// Same sign rule as for integers
+		if r == 0 {
+			return newError(node, "division by zero")
+		}
+		return &Float{Value: math.Mod(l, r)}
+
+	case "**":
+		return &Float{Value: math.Pow(l, r)}
+	}
+
+	return newError(node, "operator type mismatch. %s %s %s", FLOAT, operator, FLOAT)
+}
+
+// power computes base ** exponent by squaring. Like the other integer
+// operators it wraps around on overflow.
+func power(base, exponent int64) int64 {
+	result := int64(1)
+	for exponent > 0 {
+		if exponent&1 == 1 {
+			result *= base
+		}
+		base *= base
+		exponent >>= 1
+	}
+	return result
 }
 
 func evalPrefixExpression(expr *parser.PrefixExpression, env *Environment) Object {
-	value := Eval(expr.Right, env)
+	value := eval(expr.Right, env)
 	if isError(value) {
 		return value
 	}
 
-	if value.Type() == INTEGER && expr.Operator == "-" {
-		i := value.(*Integer)
-		i.Value = i.Value * -1
-		return value
+	if expr.Operator == "-" {
+		switch value := value.(type) {
+		case *Integer:
+			return &Integer{Value: -value.Value}
+		case *Float:
+			return &Float{Value: -value.Value}
+		}
 	}
 
 	if value.Type() == BOOLEAN && expr.Operator == "!" {
 		return toBooleanObject(!value.(*Boolean).Value)
 	}
 
-	return newError("invalid operator. %s%s", expr.Operator, value.Type())
+	return newError(expr, "invalid operator. %s%s", expr.Operator, typeName(value))
 }
 
 func evalDeclareStatement(stmt *parser.DeclareStatement, env *Environment) Object {
-	value := Eval(stmt.Expression, env)
+	value := eval(stmt.Expression, env)
+	if isError(value) {
+		return value
+	}
+
+	if fn, ok := value.(*Function); ok && fn.Name == "" {
+		fn.Name = stmt.Name.Value
+	}
+
+	return env.declare(stmt.Name, value)
+}
+
+func evalWhileStatement(stmt *parser.WhileStatement, env *Environment) Object {
+	for {
+		condition := eval(stmt.Condition, env)
+		if isError(condition) {
+			return condition
+		}
+		if condition.Type() != BOOLEAN {
+			return newError(stmt.Condition, "invalid condition for while. expected BOOLEAN, got %s", typeName(condition))
+		}
+		if condition == FALSE {
+			return NOTHING_OBJ
+		}
+
+		result := evalLoopBody(stmt.Body, NewEnclosedEnvironment(env))
+		if result != nil {
+			return result
+		}
+	}
+}
+
+func evalForStatement(stmt *parser.ForStatement, env *Environment) Object {
+	iterable := eval(stmt.Iterable, env)
+	if isError(iterable) {
+		return iterable
+	}
+
+	var elements []Object
+	switch iterable := iterable.(type) {
+	case *Array:
+		elements = iterable.Elements
+	case *Hash:
+		for _, key := range iterable.Order {
+			elements = append(elements, iterable.Pairs[key].Key)
+		}
+	case *String:
+		for _, char := range iterable.Value {
+			elements = append(elements, &String{Value: string(char)})
+		}
+	default:
+		return newError(stmt.Iterable, "cannot iterate over %s", typeName(iterable))
+	}
+
+	for _, element := range elements {
+		scope := NewEnclosedEnvironment(env)
+		scope.declare(stmt.Variable, element)
+
+		result := evalLoopBody(stmt.Body, scope)
+		if result != nil {
+			return result
+		}
+	}
+
+	return NOTHING_OBJ
+}
+
+// evalLoopBody runs one iteration of a loop. It returns the result the loop
+// has to end with, or nil if the loop goes on.
+func evalLoopBody(body *parser.BlockStatement, env *Environment) Object {
+	switch result := eval(body, env).(type) {
+	case *Error, *ReturnValue:
+		return result
+	case *Break:
+		return NOTHING_OBJ
+	}
+	return nil
+}
+
+// evalEnumDeclaration binds the name of an enum. Its variants with fields
+// are constructors, the others are their only value.
+func evalEnumDeclaration(decl *parser.EnumDeclaration, env *Environment) Object {
+	enum := &Enum{Declaration: decl, Variants: make(map[string]Object, len(decl.Variants))}
+	for _, variant := range decl.Variants {
+		var value Object = &EnumValue{Enum: decl.Name.Value, Variant: variant}
+		if len(variant.Fields) > 0 {
+			value = enumConstructor(decl.Name.Value, variant)
+		}
+		enum.Variants[variant.Name.Value] = value
+	}
+
+	if declared := env.declare(decl.Name, enum); isError(declared) {
+		return declared
+	}
+	return NOTHING_OBJ
+}
+
+// enumConstructor returns the function creating values of a variant from its
+// payload.
+func enumConstructor(enum string, variant *parser.EnumVariant) *Builtin {
+	name := enum + "." + variant.Name.Value
+	return &Builtin{
+		Name: name,
+		Fn: func(call *parser.FunctionCall, args ...Object) Object {
+			if err := expectArguments(call, name, args, len(variant.Fields)); err != nil {
+				err.Labels = append(err.Labels, Label{
+					Pos:     variant.Pos(),
+					Length:  utf8.RuneCountInString(variant.Name.Value),
+					Message: "declared here",
+				})
+				return err
+			}
+			return &EnumValue{Enum: enum, Variant: variant, Payload: args}
+		},
+	}
+}
+
+// hoistFunctions declares all named functions of a block before any of its
+// statements run, so functions can call each other regardless of their order.
+func hoistFunctions(stmts []parser.Statement, env *Environment) *Error {
+	for _, stmt := range stmts {
+		decl, ok := stmt.(*parser.FunctionDeclaration)
+		if !ok {
+			continue
+		}
+
+		fn := &Function{
+			Name:       decl.Name.Value,
+			Parameters: decl.Function.Parameters,
+			Body:       decl.Function.Body,
+			Env:        env,
+			Pos:        decl.Pos(),
+		}
+		if err, ok := env.declare(decl.Name, fn).(*Error); ok {
+			return err
+		}
+	}
+	return nil
+}
+
+func evalAssignStatement(stmt *parser.AssignStatement, env *Environment) Object {
+	value := eval(stmt.Expression, env)
 	if isError(value) {
 		return value
 	}
 
-	return env.set(stmt.Name, value)
+	if operator := stmt.Operator(); operator != "" {
+		current, ok := env.lookup(stmt.Name.Value)
+		if !ok {
+			return undeclaredAssignment(stmt.Name)
+		}
+		value = evalInfixOperator(stmt, operator, current.value, value)
+		if isError(value) {
+			return value
+		}
+	}
+
+	return env.assign(stmt.Name, value)
 }
 
 func evalBlockStatement(block *parser.BlockStatement, env *Environment) Object {
-	var result Object
+	if err := hoistFunctions(block.Statements, env); err != nil {
+		return err
+	}
+
+	var result Object = NOTHING_OBJ
 	for _, stmt := range block.Statements {
-		result = Eval(stmt, env)
+		result = eval(stmt, env)
 
-		if result.Type() == ERROR || result.Type() == RETURN {
+		switch result.Type() {
+		case ERROR, RETURN, BREAK, CONTINUE:
 			return result
 		}
 	}
@@ -230,9 +796,13 @@ func evalBlockStatement(block *parser.BlockStatement, env *Environment) Object {
 }
 
 func evalProgram(prg *parser.Program, env *Environment) Object {
-	var result Object
+	if err := hoistFunctions(prg.Statements, env); err != nil {
+		return err
+	}
+
+	var result Object = NOTHING_OBJ
 	for _, stmt := range prg.Statements {
-		result = Eval(stmt, env)
+		result = eval(stmt, env)
 
 		switch result := result.(type) {
 		case *ReturnValue:
@@ -251,10 +821,26 @@ func isError(obj Object) bool {
 	return false
 }
 
-func newError(message string, a ...interface{}) *Error {
+func newError(node parser.Node, message string, a ...interface{}) *Error {
 	return &Error{
 		Message: fmt.Sprintf(message, a...),
+		Pos:     node.Pos(),
+		Length:  spanLength(node),
+	}
+}
+
+// spanLength returns the number of columns of the node, or of its operator for
+// infix expressions. Spans across lines are cut down to their first column.
+func spanLength(node parser.Node) int {
+	if infix, ok := node.(*parser.InfixExpression); ok {
+		return utf8.RuneCountInString(infix.Operator)
+	}
+
+	start, end := node.Pos(), parser.End(node)
+	if end.Line != start.Line || end.Column <= start.Column {
+		return 1
 	}
+	return end.Column - start.Column
 }
 
 func toBooleanObject(v bool) *Boolean {
@@ -114,6 +114,28 @@ func TestEvalFunctionCall(t *testing.T) {
 	expectIntegerValue(t, actual, 5)
 }
 
+func TestEvalFunctionArity(t *testing.T) {
+	input := `var add = fn(a, b) { a + b }
+	add(1, 2, 3)`
+	actual := testEval(input)
+	expectError(t, actual, "wrong number of arguments for add. expected 2, got 3")
+	expectErrorPosition(t, actual, 2, 2)
+
+	input = `var add = fn(a, b) { a + b }
+	add(1)`
+	actual = testEval(input)
+	expectError(t, actual, "wrong number of arguments for add. expected 2, got 1")
+
+	input = `var call = fn(f) { f() }
+	call(fn(x) { x })`
+	actual = testEval(input)
+	expectError(t, actual, "wrong number of arguments for <anonymous>. expected 1, got 0")
+	expectStackTrace(t, actual, "call(FUNCTION) 2:2")
+	if labels := actual.(*evaluator.Error).Labels; len(labels) != 1 || labels[0].Pos.Column != 7 {
+		t.Fatalf("expected label pointing at the declaration. got %v", labels)
+	}
+}
+
 func TestEvalFunctionLiteral(t *testing.T) {
 	input := `fn(x) {
 		if x > 0 {
@@ -157,6 +179,389 @@ func TestEvalDeclaration(t *testing.T) {
 	x`
 	actual := testEval(input)
 	expectIntegerValue(t, actual, 1)
+
+	input = `x := 1
+	y := x + 1
+	y`
+	actual = testEval(input)
+	expectIntegerValue(t, actual, 2)
+
+	input = `x := 1
+	var foo = fn() {
+		x := 2
+		x
+	}
+	foo() + x`
+	actual = testEval(input)
+	expectIntegerValue(t, actual, 3)
+
+	input = `x := 1
+	var x = 2`
+	actual = testEval(input)
+	expectError(t, actual, "x is already declared")
+	expectErrorPosition(t, actual, 2, 6)
+	if labels := actual.(*evaluator.Error).Labels; len(labels) != 1 || labels[0].Pos.Line != 1 || labels[0].Pos.Column != 1 {
+		t.Fatalf("expected label pointing at the first declaration. got %v", labels)
+	}
+
+	input = `var foo = fn(a) {
+		a := 2
+	}
+	foo(1)`
+	actual = testEval(input)
+	expectError(t, actual, "a is already declared")
+
+	input = `fn(a, a) { a }(1, 2)`
+	actual = testEval(input)
+	expectError(t, actual, "a is already declared")
+}
+
+func TestEvalFunctionDeclaration(t *testing.T) {
+	input := `fn add(a: int, b: int) int {
+		return a + b
+	}
+	add(1, 2)`
+	actual := testEval(input)
+	expectIntegerValue(t, actual, 3)
+
+	input = `var result = isEven(10)
+	fn isEven(n) {
+		if n == 0 { return true }
+		isOdd(n - 1)
+	}
+	fn isOdd(n) {
+		if n == 0 { return false }
+		isEven(n - 1)
+	}
+	result`
+	actual = testEval(input)
+	expectBooleanValue(t, actual, true)
+
+	input = `var outer = fn(x) {
+		fn double() { x * 2 }
+		double() + 1
+	}
+	outer(4)`
+	actual = testEval(input)
+	expectIntegerValue(t, actual, 9)
+
+	input = `fn fail(x) { x + true }
+	fail(1)`
+	actual = testEval(input)
+	expectStackTrace(t, actual, "fail(1) 2:2")
+
+	input = `fn twice() {}
+	fn twice() {}`
+	actual = testEval(input)
+	expectError(t, actual, "twice is already declared")
+	expectErrorPosition(t, actual, 2, 5)
+}
+
+func TestEvalEnums(t *testing.T) {
+	declaration := `enum Shape { Circle(r), Rect(w, h), Empty }
+	`
+
+	tests := []struct {
+		input  string
+		expect string
+	}{
+		{"Shape.Circle(2)", "Shape.Circle(r: 2)"},
+		{`Shape.Rect(1.5, "wide")`, `Shape.Rect(w: 1.5, h: "wide")`},
+		{"Shape.Empty", "Shape.Empty"},
+		{"Shape", "enum Shape { Circle(r), Rect(w, h), Empty }"},
+		{"[Shape.Empty, Shape.Circle(1)]", "[Shape.Empty, Shape.Circle(r: 1)]"},
+		{`"${Shape.Circle(1)}"`, "Shape.Circle(r: 1)"},
+		{"Shape.Circle(2) == Shape.Circle(2)", "true"},
+		{"Shape.Circle(2) == Shape.Circle(3)", "false"},
+		{"Shape.Circle(2) != Shape.Circle(3)", "true"},
+		{"Shape.Circle(1) == Shape.Circle(1.0)", "true"},
+		{"Shape.Circle(1) == Shape.Rect(1, 1)", "false"},
+		{"Shape.Empty == Shape.Empty", "true"},
+		{"Shape.Empty != Shape.Empty", "false"},
+		{"Shape.Empty == 0", "false"},
+		{"Shape.Rect(3, 4).h", "4"},
+		{`Shape.Rect(3, 4)["h"]`, "4"},
+		{"circle := Shape.Circle\ncircle(5)", "Shape.Circle(r: 5)"},
+		{"a := [1]\nShape.Circle(a) == Shape.Circle(a)", "true"},
+		{"Shape.Circle([1]) == Shape.Circle([1])", "false"},
+		{"h := {a: 1}\nShape.Circle(h) == Shape.Circle(h)", "true"},
+		{"f := fn() {}\nShape.Circle(f) == Shape.Circle(f)", "true"},
+		{"Shape.Circle(len) == Shape.Circle(len)", "true"},
+	}
+
+	for _, test := range tests {
+		actual := testEval(declaration + test.input)
+		if isError(actual) {
+			t.Fatalf("unexpected error for %q: %s", test.input, actual.Inspect())
+		}
+		if actual.Inspect() != test.expect {
+			t.Fatalf("wrong result for %q\n\texpected: %s\n\tgot:      %s", test.input, test.expect, actual.Inspect())
+		}
+	}
+
+	input := `enum Light { Red, Yellow, Green }
+	fn next(light) {
+		if light == Light.Red { return Light.Green }
+		if light == Light.Green { return Light.Yellow }
+		Light.Red
+	}
+	var light = Light.Red
+	for i in [1, 2, 3, 4] {
+		light = next(light)
+	}
+	light == Light.Green`
+	actual := testEval(input)
+	expectBooleanValue(t, actual, true)
+
+	// Variants of different enums can have the same name
+	input = `enum Opt { None, Some(v) }
+	enum Res { None, Err(e) }
+	[Opt.None == Opt.None, Opt.None == Res.None]`
+	actual = testEval(input)
+	if actual.Inspect() != "[true, false]" {
+		t.Fatalf("wrong result for variants with the same name. got %s", actual.Inspect())
+	}
+
+	actual = testEval(declaration + `Shape.Circle(1)["d"]`)
+	expectError(t, actual, "Shape.Circle has no field d")
+
+	actual = testEval(declaration + "Shape.Circle(1).d")
+	expectError(t, actual, "Shape.Circle has no field d")
+	expectErrorPosition(t, actual, 2, 18)
+
+	actual = testEval(declaration + "Shape.Square")
+	expectError(t, actual, "Shape has no variant Square")
+	expectErrorPosition(t, actual, 2, 8)
+
+	actual = testEval(declaration + "Shape.Circle(1, 2)")
+	expectError(t, actual, "wrong number of arguments for Shape.Circle. expected 1, got 2")
+
+	actual = testEval(declaration + "[1].length")
+	expectError(t, actual, "member access not supported. ARRAY.length")
+
+	actual = testEval(declaration + "Shape.Circle(1) + Shape.Empty")
+	expectError(t, actual, "operator type mismatch. Shape + Shape")
+
+	actual = testEval(declaration + "-Shape.Empty")
+	expectError(t, actual, "invalid operator. -Shape")
+
+	actual = testEval(declaration + "for x in Shape.Empty {}")
+	expectError(t, actual, "cannot iterate over Shape")
+
+	actual = testEval(declaration + "enum Shape { Empty }")
+	expectError(t, actual, "Shape is already declared")
+	expectErrorPosition(t, actual, 2, 7)
+}
+
+func TestEvalAssignment(t *testing.T) {
+	tests := []struct {
+		input  string
+		expect int64
+	}{
+		{"x := 1\nx = 2\nx", 2},
+		{"x := 1\nx += 2\nx", 3},
+		{"x := 1\nx -= 2\nx", -1},
+		{"x := 3\nx *= 2\nx", 6},
+		{"x := 7\nx /= 2\nx", 3},
+		{"x := 1\nvar inc = fn() { x += 1 }\ninc()\ninc()\nx", 3},
+		{"sum := 0\nfn add(n) { if n > 0 { sum += n\nadd(n - 1) } }\nadd(4)\nsum", 10},
+		{`var counter = fn() {
+			count := 0
+			fn() {
+				count += 1
+				count
+			}
+		}
+		var next = counter()
+		next()
+		next()`, 2},
+		{"x := 1\nvar shadow = fn() { x := 5\nx = 6 }\nshadow()\nx", 1},
+	}
+
+	for _, test := range tests {
+		actual := testEval(test.input)
+		expectIntegerValue(t, actual, test.expect)
+	}
+
+	actual := testEval("y = 1")
+	expectError(t, actual, "cannot assign to undeclared variable y")
+	expectErrorPosition(t, actual, 1, 1)
+
+	actual = testEval("y += 1")
+	expectError(t, actual, "cannot assign to undeclared variable y")
+	expectErrorPosition(t, actual, 1, 1)
+
+	actual = testEval("len += 1")
+	expectError(t, actual, "cannot assign to builtin len")
+
+	actual = testEval("len = 1")
+	expectError(t, actual, "cannot assign to builtin len")
+
+	actual = testEval("x := 1\nx += true")
+	expectError(t, actual, "operator type mismatch. INTEGER + BOOLEAN")
+	expectErrorPosition(t, actual, 2, 3)
+
+	actual = testEval("x := 1\nx /= 0")
+	expectError(t, actual, "division by zero")
+}
+
+func TestEvalScopes(t *testing.T) {
+	tests := []struct {
+		input  string
+		expect int64
+	}{
+		// Closures share the variables of the scope they were created in
+		{`var counter = fn() {
+			count := 0
+			var inc = fn() { count += 1 }
+			var get = fn() { count }
+			[inc, get]
+		}
+		var c = counter()
+		c[0]()
+		c[0]()
+		c[1]()`, 2},
+		// Variables declared after a function are visible to it
+		{`var get = fn() { later }
+		var later = 3
+		get()`, 3},
+		// Branches of an if expression are scopes of their own
+		{`x := 1
+		if true {
+			x := 2
+			x = 3
+		}
+		x`, 1},
+		{`x := 1
+		if false {} else { x = 4 }
+		x`, 4},
+		// Parameters shadow outer variables
+		{`x := 1
+		fn f(x) { x = 10 }
+		f(5)
+		x`, 1},
+	}
+
+	for _, test := range tests {
+		actual := testEval(test.input)
+		expectIntegerValue(t, actual, test.expect)
+	}
+
+	actual := testEval(`if true { y := 1 }
+	y`)
+	expectError(t, actual, "unknown identifier y")
+}
+
+func TestEvalLoops(t *testing.T) {
+	tests := []struct {
+		input  string
+		expect int64
+	}{
+		{`i := 0
+		while i < 100000 { i += 1 }
+		i`, 100000},
+		{`sum := 0
+		for x in [1, 2, 3, 4] { sum += x }
+		sum`, 10},
+		{`n := 0
+		for key in {a: 1, b: 2} { n += 1 }
+		n`, 2},
+		{`n := 0
+		for char in "äöü" { n += 1 }
+		n`, 3},
+		{`i := 0
+		while true {
+			i += 1
+			if i == 5 { break }
+		}
+		i`, 5},
+		{`odd := 0
+		for x in [1, 2, 3, 4, 5] {
+			if x / 2 * 2 == x { continue }
+			odd += x
+		}
+		odd`, 9},
+		{`fn find(xs, wanted) {
+			i := 0
+			for x in xs {
+				if x == wanted { return i }
+				i += 1
+			}
+			-1
+		}
+		find([5, 6, 7], 7)`, 2},
+		// Every iteration gets its own scope
+		{`fns := [0, 0, 0]
+		for x in [1, 2, 3] {
+			y := x * 10
+			fns = [fn() { y }, fns[0], fns[1]]
+		}
+		fns[2]()`, 10},
+	}
+
+	for _, test := range tests {
+		actual := testEval(test.input)
+		expectIntegerValue(t, actual, test.expect)
+	}
+
+	actual := testEval("while true { x }")
+	expectError(t, actual, "unknown identifier x")
+
+	actual = testEval("for x in 5 {}")
+	expectError(t, actual, "cannot iterate over INTEGER")
+	expectErrorPosition(t, actual, 1, 10)
+
+	actual = testEval("while false {}")
+	expectNothingValue(t, actual)
+
+	actual = testEval("while 1 {}")
+	expectError(t, actual, "invalid condition for while. expected BOOLEAN, got INTEGER")
+	expectErrorPosition(t, actual, 1, 7)
+
+	actual = testEval(`i := 0
+	while i { i += 1 }`)
+	expectError(t, actual, "invalid condition for while. expected BOOLEAN, got INTEGER")
+}
+
+func TestEvalLogicalOperators(t *testing.T) {
+	tests := []struct {
+		input  string
+		expect bool
+	}{
+		{"true && true", true},
+		{"true && false", false},
+		{"false || true", true},
+		{"false || false", false},
+		{"1 < 2 && 2 < 3", true},
+		{"1 > 2 || 2 > 3", false},
+		// The right side is not evaluated if the left side decides
+		{"false && undefined", false},
+		{"true || 1 / 0 == 1", true},
+		{`calls := 0
+		fn count() {
+			calls += 1
+			true
+		}
+		false && count()
+		true || count()
+		calls == 0`, true},
+	}
+
+	for _, test := range tests {
+		actual := testEval(test.input)
+		expectBooleanValue(t, actual, test.expect)
+	}
+
+	actual := testEval("1 && true")
+	expectError(t, actual, "invalid operand for &&. expected BOOLEAN, got INTEGER")
+	expectErrorPosition(t, actual, 1, 1)
+
+	actual = testEval(`false || "yes"`)
+	expectError(t, actual, "invalid operand for ||. expected BOOLEAN, got STRING")
+	expectErrorPosition(t, actual, 1, 10)
+
+	actual = testEval("true && x")
+	expectError(t, actual, "unknown identifier x")
 }
 
 func TestEvalErrorHandling(t *testing.T) {
@@ -213,6 +618,133 @@ func TestEvalErrorHandling(t *testing.T) {
 	expectError(t, actual, "unknown identifier x")
 }
 
+func TestEvalErrorPosition(t *testing.T) {
+	input := `var foo = fn(y) {
+		return x + y
+	}
+	foo(1)`
+	actual := testEval(input)
+	expectError(t, actual, "unknown identifier x")
+	expectErrorPosition(t, actual, 2, 10)
+
+	input = `var a = 1
+	a + true`
+	actual = testEval(input)
+	expectErrorPosition(t, actual, 2, 4)
+}
+
+func TestEvalRuntimePanics(t *testing.T) {
+	input := "10 / (5 - 5)"
+	actual := testEval(input)
+	expectError(t, actual, "division by zero")
+	expectErrorPosition(t, actual, 1, 4)
+
+	input = `var f = fn(n) { f(n + 1) }
+	f(0)`
+	actual = testEval(input)
+	expectError(t, actual, "maximum call depth of 10000 exceeded")
+
+	input = "fn() {}()"
+	actual = testEval(input)
+	expectNothingValue(t, actual)
+
+	actual = evaluator.Eval(&parser.ExpressionStatement{}, evaluator.NewEnvrionment())
+	expectError(t, actual, "missing expression")
+
+	actual = evaluator.Eval(&parser.Program{Statements: []parser.Statement{nil}}, evaluator.NewEnvrionment())
+	expectError(t, actual, "missing expression")
+
+	actual = evaluator.Eval(&parser.IfExpression{Condition: &parser.BooleanLiteral{Value: true}}, evaluator.NewEnvrionment())
+	expectError(t, actual, "internal error: runtime error: invalid memory address or nil pointer dereference")
+}
+
+func TestEvalExit(t *testing.T) {
+	input := `var stop = fn(code) {
+		exit(code)
+		return 1
+	}
+	stop(3)
+	2`
+	actual := testEval(input)
+	expectExit(t, actual, 3)
+
+	input = "exit()"
+	actual = testEval(input)
+	expectExit(t, actual, 0)
+
+	input = "exit(256)"
+	actual = testEval(input)
+	expectError(t, actual, "exit code must be between 0 and 255. got 256")
+
+	input = "exit(true)"
+	actual = testEval(input)
+	expectError(t, actual, "exit code must be INTEGER. got BOOLEAN")
+
+	input = `var exit = fn(x) { x }
+	exit(5)`
+	actual = testEval(input)
+	expectIntegerValue(t, actual, 5)
+}
+
+func expectExit(t *testing.T, actual evaluator.Object, code int64) {
+	exit, ok := actual.(*evaluator.Exit)
+	if !ok {
+		t.Fatalf("expected exit value. got %T %s", actual, actual.Inspect())
+	}
+	if exit.Code != code {
+		t.Fatalf("wrong exit code. expected %d got %d", code, exit.Code)
+	}
+}
+
+func TestEvalStackTrace(t *testing.T) {
+	input := `var inner = fn(x) {
+		return x + y
+	}
+	var outer = fn(a, b) {
+		inner(a)
+	}
+	outer(1, "two")`
+	actual := testEval(input)
+	expectError(t, actual, "unknown identifier y")
+	expectStackTrace(t, actual, `inner(1) 5:3`, `outer(1, "two") 7:2`)
+
+	input = `fn(x) {
+		fn() { x + true }()
+	}(1)`
+	actual = testEval(input)
+	expectStackTrace(t, actual, "<anonymous>() 2:3", "<anonymous>(1) 1:1")
+
+	input = `var foo = fn(x) { x + true }
+	foo(foo(1))`
+	actual = testEval(input)
+	expectStackTrace(t, actual, "foo(1) 2:6")
+}
+
+func expectStackTrace(t *testing.T, actual evaluator.Object, expect ...string) {
+	errorValue, ok := actual.(*evaluator.Error)
+	if !ok {
+		t.Fatalf("expected error value. got %T", actual)
+	}
+	if len(errorValue.Stack) != len(expect) {
+		t.Fatalf("wrong number of stack frames. expected %d got %d\n%s", len(expect), len(errorValue.Stack), errorValue.StackTrace())
+	}
+	for i, frame := range errorValue.Stack {
+		if frame.String() != expect[i] {
+			t.Fatalf("wrong stack frame %d\n\texpected: %s\n\tgot:      %s", i, expect[i], frame.String())
+		}
+	}
+}
+
+func expectErrorPosition(t *testing.T, actual evaluator.Object, line, column int) {
+	errorValue, ok := actual.(*evaluator.Error)
+	if !ok {
+		t.Fatalf("expected error value. got %T", actual)
+	}
+	if errorValue.Pos.Line != line || errorValue.Pos.Column != column {
+		t.Fatalf("wrong error position. expected %d:%d got %s", line, column, errorValue.Pos)
+	}
+}
+
 func TestEvalReturn(t *testing.T) {
 	input := `1 + 1
 	return true
@@ -306,6 +838,279 @@ func TestEvalInfixExpression(t *testing.T) {
 	expectBooleanValue(t, actual, false)
 }
 
+func TestEvalArithmeticOperators(t *testing.T) {
+	booleans := []struct {
+		input  string
+		expect bool
+	}{
+		{"1 <= 2", true},
+		{"2 <= 2", true},
+		{"3 <= 2", false},
+		{"1 >= 2", false},
+		{"2 >= 2", true},
+		{"3 >= 2", true},
+	}
+
+	for _, test := range booleans {
+		actual := testEval(test.input)
+		expectBooleanValue(t, actual, test.expect)
+	}
+
+	integers := []struct {
+		input  string
+		expect int64
+	}{
+		{"7 % 3", 1},
+		{"-7 % 3", -1},
+		{"7 % -3", 1},
+		{"-7 % -3", -1},
+		{"6 % 3", 0},
+		{"2 ** 10", 1024},
+		{"2 ** 0", 1},
+		{"0 ** 0", 1},
+		{"(-3) ** 3", -27},
+		{"-2 ** 2", -4},
+		{"2 ** 3 ** 2", 512},
+		{"1 + 2 * 3 ** 2 % 5", 4},
+	}
+
+	for _, test := range integers {
+		actual := testEval(test.input)
+		expectIntegerValue(t, actual, test.expect)
+	}
+
+	actual := testEval("5 % 0")
+	expectError(t, actual, "division by zero")
+
+	actual = testEval("2 ** -1")
+	expectError(t, actual, "negative exponent -1. integers can only be raised to non-negative powers")
+	expectErrorPosition(t, actual, 1, 3)
+
+	actual = testEval(`"a" <= "b"`)
+	expectError(t, actual, "operator type mismatch. STRING <= STRING")
+}
+
+func TestEvalFloats(t *testing.T) {
+	tests := []struct {
+		input  string
+		expect string
+	}{
+		{"1.5", "1.5"},
+		{"2.0", "2.0"},
+		{"1e3", "1000.0"},
+		{"1e21", "1e+21"},
+		{"0.1 + 0.2", "0.30000000000000004"},
+		{"1 + 0.5", "1.5"},
+		{"0.5 * 4", "2.0"},
+		{"7 / 2.0", "3.5"},
+		{"-7.5 % 2", "-1.5"},
+		{"2 ** 0.5", "1.4142135623730951"},
+		{"-1.5", "-1.5"},
+		{"float(3)", "3.0"},
+		{`float("2.5")`, "2.5"},
+		{"int(3.9)", "3"},
+		{"int(-3.9)", "-3"},
+		{`int("42")`, "42"},
+		{"int(7)", "7"},
+	}
+
+	for _, test := range tests {
+		actual := testEval(test.input)
+		if isError(actual) {
+			t.Fatalf("unexpected error for %q: %s", test.input, actual.Inspect())
+		}
+		if actual.Inspect() != test.expect {
+			t.Fatalf("wrong result for %q\n\texpected: %s\n\tgot:      %s", test.input, test.expect, actual.Inspect())
+		}
+
+		// Printed floats read back as the same value
+		if float, ok := actual.(*evaluator.Float); ok {
+			again, ok := testEval(float.Inspect()).(*evaluator.Float)
+			if !ok || again.Value != float.Value {
+				t.Fatalf("%s does not round-trip. got %v", float.Inspect(), again)
+			}
+		}
+	}
+
+	booleans := []struct {
+		input  string
+		expect bool
+	}{
+		{"1 == 1.0", true},
+		{"1.5 > 1", true},
+		{"2 <= 1.5", false},
+		{"0.1 + 0.2 != 0.3", true},
+	}
+
+	for _, test := range booleans {
+		actual := testEval(test.input)
+		expectBooleanValue(t, actual, test.expect)
+	}
+
+	actual := testEval("1.0 / 0")
+	expectError(t, actual, "division by zero")
+
+	actual = testEval(`int("abc")`)
+	expectError(t, actual, `cannot convert "abc" to INTEGER`)
+
+	actual = testEval("int(1e30)")
+	expectError(t, actual, "cannot convert 1e+30 to INTEGER")
+
+	actual = testEval(`float(true)`)
+	expectError(t, actual, "argument to float not supported. got BOOLEAN")
+
+	actual = testEval(`1.5 + "a"`)
+	expectError(t, actual, "operator type mismatch. FLOAT + STRING")
+}
+
+func TestEvalNegationDoesNotChangeVariables(t *testing.T) {
+	actual := testEval(`x := 5
+	y := -x
+	x + y * 0`)
+	expectIntegerValue(t, actual, 5)
+}
+
+func isError(obj evaluator.Object) bool {
+	_, ok := obj.(*evaluator.Error)
+	return ok
+}
+
+func TestEvalArrays(t *testing.T) {
+	input := `[1, 2 * 2, "three"]`
+	actual := testEval(input)
+	expectArrayValue(t, actual, `[1, 4, "three"]`)
+
+	input = `var list = [1, [2, 3]]
+	list[1][0] + list[0]`
+	actual = testEval(input)
+	expectIntegerValue(t, actual, 3)
+
+	input = `fn() { [1, 2] }()[1]`
+	actual = testEval(input)
+	expectIntegerValue(t, actual, 2)
+
+	input = `[1, 2, 3][3]`
+	actual = testEval(input)
+	expectError(t, actual, "index 3 out of range for array of length 3")
+	expectErrorPosition(t, actual, 1, 11)
+
+	input = `[1, 2, 3][-1]`
+	actual = testEval(input)
+	expectError(t, actual, "index -1 out of range for array of length 3")
+
+	input = `[1][true]`
+	actual = testEval(input)
+	expectError(t, actual, "index operator not supported. ARRAY[BOOLEAN]")
+
+	input = `[1, x]`
+	actual = testEval(input)
+	expectError(t, actual, "unknown identifier x")
+}
+
+func TestEvalLen(t *testing.T) {
+	input := `len([1, 2, 3])`
+	actual := testEval(input)
+	expectIntegerValue(t, actual, 3)
+
+	input = `len([])`
+	actual = testEval(input)
+	expectIntegerValue(t, actual, 0)
+
+	input = `len("hello")`
+	actual = testEval(input)
+	expectIntegerValue(t, actual, 5)
+
+	input = `len("äöü")`
+	actual = testEval(input)
+	expectIntegerValue(t, actual, 3)
+
+	input = `len("a\tb\u{1F600}")`
+	actual = testEval(input)
+	expectIntegerValue(t, actual, 4)
+
+	input = `len(1)`
+	actual = testEval(input)
+	expectError(t, actual, "argument to len not supported. got INTEGER")
+
+	input = `len([], [])`
+	actual = testEval(input)
+	expectError(t, actual, "wrong number of arguments for len. expected 1, got 2")
+}
+
+func TestEvalHashes(t *testing.T) {
+	input := `var age = 1
+	{name: "thorsten", age: 28, 1: true, true: 2}`
+	actual := testEval(input)
+	expectHashValue(t, actual, `{"name": "thorsten", "age": 28, 1: true, true: 2}`)
+
+	input = `var key = "b"
+	var map = {"a": 1, (key): 2, 1 + 1: 3}
+	map["a"] + map["b"] + map[2]`
+	actual = testEval(input)
+	expectIntegerValue(t, actual, 6)
+
+	input = `{a: 1, a: 2}`
+	actual = testEval(input)
+	expectHashValue(t, actual, `{"a": 2}`)
+
+	input = `{a: 1}["b"]`
+	actual = testEval(input)
+	expectError(t, actual, `key "b" not found`)
+
+	input = `{a: 1}[[1]]`
+	actual = testEval(input)
+	expectError(t, actual, "unusable as hash key: ARRAY")
+
+	input = `{[1]: 1}`
+	actual = testEval(input)
+	expectError(t, actual, "unusable as hash key: ARRAY")
+
+	input = `var map = {a: 1, b: [2]}
+	keys(map)`
+	actual = testEval(input)
+	expectArrayValue(t, actual, `["a", "b"]`)
+
+	input = `values({a: 1, b: [2]})`
+	actual = testEval(input)
+	expectArrayValue(t, actual, `[1, [2]]`)
+
+	input = `has({a: 1}, "a")`
+	actual = testEval(input)
+	expectBooleanValue(t, actual, true)
+
+	input = `has({a: 1}, 1)`
+	actual = testEval(input)
+	expectBooleanValue(t, actual, false)
+
+	input = `len({a: 1, b: 2})`
+	actual = testEval(input)
+	expectIntegerValue(t, actual, 2)
+
+	input = `keys([1])`
+	actual = testEval(input)
+	expectError(t, actual, "argument to keys must be HASH. got ARRAY")
+}
+
+func expectHashValue(t *testing.T, v evaluator.Object, expect string) {
+	obj, ok := v.(*evaluator.Hash)
+	if !ok {
+		t.Fatalf("Expected hash value %s, got %T %s", expect, v, v.Inspect())
+	}
+	if obj.Inspect() != expect {
+		t.Fatalf("Expected evaluated value to be %s. got %s", expect, obj.Inspect())
+	}
+}
+
+func expectArrayValue(t *testing.T, v evaluator.Object, expect string) {
+	obj, ok := v.(*evaluator.Array)
+	if !ok {
+		t.Fatalf("Expected array value %s, got %T %s", expect, v, v.Inspect())
+	}
+	if obj.Inspect() != expect {
+		t.Fatalf("Expected evaluated value to be %s. got %s", expect, obj.Inspect())
+	}
+}
+
 func TestEvalBooleanLiteral(t *testing.T) {
 	input := "true"
 	actual := testEval(input)
@@ -342,6 +1147,47 @@ func TestEvalStringLitearl(t *testing.T) {
 	expectBooleanValue(t, actual, true)
 }
 
+func TestEvalInterpolatedString(t *testing.T) {
+	tests := []struct {
+		input  string
+		expect string
+	}{
+		{`name := "thorsten"
+		age := 28
+		"hello ${name}, you are ${age + 1}"`, "hello thorsten, you are 29"},
+		{`"${1.5} ${true} ${[1, "a"]} ${{a: "b"}}"`, `1.5 true [1, "a"] {"a": "b"}`},
+		{`"n=${1}" + "!"`, "n=1!"},
+		{`x := "in"
+		"out ${"${x}ner"} \${x}"`, "out inner ${x}"},
+		{`x := 1
+		"v=${ {
+			a: x
+		}["a"] }"`, "v=1"},
+	}
+
+	for _, test := range tests {
+		actual := testEval(test.input)
+		expectStringValue(t, actual, test.expect)
+	}
+
+	actual := testEval(`"a ${b} c"`)
+	expectError(t, actual, "unknown identifier b")
+	expectErrorPosition(t, actual, 1, 6)
+}
+
+func TestEvalMultilineStrings(t *testing.T) {
+	input := `fn letter(name) {
+		greeting := """
+			Dear ${name},
+			  thanks!
+			"""
+		greeting + "|" + ` + "`raw\\n\nline`" + `
+	}
+	letter("x")`
+	actual := testEval(input)
+	expectStringValue(t, actual, "Dear ${name},\n  thanks!|raw\\n\nline")
+}
+
 func TestEvalIntegerLiteral(t *testing.T) {
 	input := "1"
 	actual := testEval(input)
@@ -366,6 +1212,10 @@ func TestEvalIntegerLiteral(t *testing.T) {
 	input = "(5 + 10 * 2 + 15 / 3) * 2 + -10"
 	actual = testEval(input)
 	expectIntegerValue(t, actual, 50)
+
+	input = "0xff + 0o10 + 0b11 + 1_000"
+	actual = testEval(input)
+	expectIntegerValue(t, actual, 1266)
 }
 
 func expectError(t *testing.T, actual evaluator.Object, expect string) {
@@ -1,21 +1,35 @@
 package evaluator
 
 import (
+	"bytes"
 	"fmt"
+	"strconv"
+	"strings"
 
 	"github.com/maiksch/best-lang/parser"
+	"github.com/maiksch/best-lang/token"
 )
 
 type ObjectType string
 
 const (
 	INTEGER  ObjectType = "INTEGER"
+	FLOAT    ObjectType = "FLOAT"
 	STRING   ObjectType = "STRING"
 	BOOLEAN  ObjectType = "BOOLEAN"
 	FUNCTION ObjectType = "FUNCTION"
 	RETURN   ObjectType = "RETURN"
 	ERROR    ObjectType = "ERROR"
 	NOTHING  ObjectType = "NOTHING"
+	BUILTIN  ObjectType = "BUILTIN"
+	ARRAY    ObjectType = "ARRAY"
+	HASH     ObjectType = "HASH"
+	EXIT     ObjectType = "EXIT"
+	BREAK    ObjectType = "BREAK"
+	CONTINUE ObjectType = "CONTINUE"
+	ENUM     ObjectType = "ENUM"
+	// ENUM_TYPE is the type of the enum itself, ENUM the one of its values
+	ENUM_TYPE ObjectType = "ENUM_TYPE"
 )
 
 type Object interface {
@@ -23,6 +37,20 @@ type Object interface {
 	Inspect() string
 }
 
+// Hashable is implemented by objects that can be used as keys of a Hash.
+type Hashable interface {
+	Object
+	HashKey() HashKey
+}
+
+// HashKey identifies a hashable value. Two objects have the same key exactly
+// when they are equal.
+type HashKey struct {
+	Type  ObjectType
+	Value int64
+	Text  string
+}
+
 /**
 * Integers
  */
@@ -33,6 +61,27 @@ type Integer struct {
 
 func (i *Integer) Type() ObjectType { return INTEGER }
 func (i *Integer) Inspect() string  { return fmt.Sprintf("%d", i.Value) }
+func (i *Integer) HashKey() HashKey { return HashKey{Type: INTEGER, Value: i.Value} }
+
+/**
+* Floats
+ */
+
+type Float struct {
+	Value float64
+}
+
+func (f *Float) Type() ObjectType { return FLOAT }
+
+// Inspect prints the shortest representation that reads back as the same
+// float. Whole numbers keep a decimal point, so 2.0 is not printed as 2.
+func (f *Float) Inspect() string {
+	s := strconv.FormatFloat(f.Value, 'g', -1, 64)
+	if !strings.ContainsAny(s, ".eIN") {
+		s += ".0"
+	}
+	return s
+}
 
 /**
 * Strings
@@ -44,6 +93,67 @@ type String struct {
 
 func (i *String) Type() ObjectType { return STRING }
 func (i *String) Inspect() string  { return fmt.Sprintf("%s", i.Value) }
+func (i *String) HashKey() HashKey { return HashKey{Type: STRING, Text: i.Value} }
+
+/**
+* Arrays
+ */
+
+type Array struct {
+	Elements []Object
+}
+
+func (a *Array) Type() ObjectType { return ARRAY }
+func (a *Array) Inspect() string {
+	elements := make([]string, len(a.Elements))
+	for i, element := range a.Elements {
+		elements[i] = inspectQuoted(element)
+	}
+	return "[" + strings.Join(elements, ", ") + "]"
+}
+
+/**
+* Hashes
+ */
+
+type HashPair struct {
+	Key   Hashable
+	Value Object
+}
+
+// Hash maps hashable keys to values. It remembers the order in which keys
+// were added.
+type Hash struct {
+	Pairs map[HashKey]HashPair
+	Order []HashKey
+}
+
+func NewHash() *Hash {
+	return &Hash{Pairs: make(map[HashKey]HashPair)}
+}
+
+func (h *Hash) Type() ObjectType { return HASH }
+func (h *Hash) Inspect() string {
+	pairs := make([]string, len(h.Order))
+	for i, key := range h.Order {
+		pair := h.Pairs[key]
+		pairs[i] = inspectQuoted(pair.Key) + ": " + inspectQuoted(pair.Value)
+	}
+	return "{" + strings.Join(pairs, ", ") + "}"
+}
+
+func (h *Hash) Set(key Hashable, value Object) {
+	hashKey := key.HashKey()
+	if _, ok := h.Pairs[hashKey]; !ok {
+		h.Order = append(h.Order, hashKey)
+	}
+	h.Pairs[hashKey] = HashPair{Key: key, Value: value}
+}
+
+func (h *Hash) Get(key Hashable) (Object, bool) {
+	pair, ok := h.Pairs[key.HashKey()]
+	return pair.Value, ok
+}
 
 /**
 * Boolean
@@ -60,20 +170,109 @@ type Boolean struct {
 
 func (b *Boolean) Type() ObjectType { return BOOLEAN }
 func (b *Boolean) Inspect() string  { return fmt.Sprintf("%t", b.Value) }
+func (b *Boolean) HashKey() HashKey {
+	if b.Value {
+		return HashKey{Type: BOOLEAN, Value: 1}
+	}
+	return HashKey{Type: BOOLEAN, Value: 0}
+}
+
+/**
+* Enums
+ */
+
+// Enum is what the name of an enum declaration is bound to. Its variants are
+// reached with a dot, like Shape.Circle.
+type Enum struct {
+	Declaration *parser.EnumDeclaration
+	// Variants holds the constructors of variants with fields and the values
+	// of variants without.
+	Variants map[string]Object
+}
+
+func (e *Enum) Type() ObjectType { return ENUM_TYPE }
+func (e *Enum) Inspect() string  { return e.Declaration.String() }
+
+// EnumValue is a variant of an enum together with its payload. Values of
+// variants without fields have no payload.
+type EnumValue struct {
+	// Enum is the name of the enum the variant belongs to.
+	Enum    string
+	Variant *parser.EnumVariant
+	Payload []Object
+}
+
+func (e *EnumValue) Type() ObjectType { return ENUM }
+
+// Inspect shows the variant and its payload by field, like
+// Shape.Rect(w: 1, h: 2).
+func (e *EnumValue) Inspect() string {
+	if len(e.Payload) == 0 {
+		return e.variantName()
+	}
+	fields := make([]string, len(e.Payload))
+	for i, value := range e.Payload {
+		fields[i] = e.Variant.Fields[i].Value + ": " + inspectQuoted(value)
+	}
+	return e.variantName() + "(" + strings.Join(fields, ", ") + ")"
+}
+
+// variantName returns the variant together with its enum, like Shape.Rect.
+func (e *EnumValue) variantName() string {
+	return e.Enum + "." + e.Variant.Name.Value
+}
+
+// Field returns the payload stored in the field with the given name.
+func (e *EnumValue) Field(name string) (Object, bool) {
+	for i, field := range e.Variant.Fields {
+		if field.Value == name {
+			return e.Payload[i], true
+		}
+	}
+	return nil, false
+}
 
 /**
 * Function
  */
 
+const ANONYMOUS = "<anonymous>"
+
 type Function struct {
+	// Name is the name the function was first bound to. It is empty for
+	// functions that were never assigned to a variable.
+	Name       string
 	Parameters []*parser.Identifier
 	Body       *parser.BlockStatement
 	Env        *Environment
+	// Pos is the position of the function literal.
+	Pos token.Position
 }
 
 func (f *Function) Type() ObjectType { return FUNCTION }
 func (f *Function) Inspect() string  { return "FUNCTION" }
 
+func (f *Function) displayName() string {
+	if f.Name == "" {
+		return ANONYMOUS
+	}
+	return f.Name
+}
+
+/**
+* Builtin
+ */
+
+type BuiltinFunction func(call *parser.FunctionCall, args ...Object) Object
+
+type Builtin struct {
+	Name string
+	Fn   BuiltinFunction
+}
+
+func (b *Builtin) Type() ObjectType { return BUILTIN }
+func (b *Builtin) Inspect() string  { return "BUILTIN " + b.Name }
+
 /**
 * Return
  */
@@ -85,16 +284,109 @@ type ReturnValue struct {
 func (r *ReturnValue) Type() ObjectType { return RETURN }
 func (r *ReturnValue) Inspect() string  { return r.Value.Inspect() }
 
+/**
+* Break and Continue
+ */
+
+// Break and Continue travel out of the loop body like a ReturnValue travels
+// out of a function body.
+
+var BREAK_OBJ = &Break{}
+
+type Break struct{}
+
+func (b *Break) Type() ObjectType { return BREAK }
+func (b *Break) Inspect() string  { return "break" }
+
+var CONTINUE_OBJ = &Continue{}
+
+type Continue struct{}
+
+func (c *Continue) Type() ObjectType { return CONTINUE }
+func (c *Continue) Inspect() string  { return "continue" }
+
 /**
 * Error
  */
 
 type Error struct {
 	Message string
+	Pos     token.Position
+	// Length is the number of columns the error spans, starting at Pos.
+	Length int
+	// Stack holds the function calls the error travelled through, starting
+	// with the innermost call.
+	Stack []StackFrame
+	// Labels point at other source locations related to the error.
+	Labels []Label
+}
+
+// Label is a secondary source location attached to an error.
+type Label struct {
+	Pos     token.Position
+	Length  int
+	Message string
 }
 
 func (e *Error) Type() ObjectType { return ERROR }
-func (e *Error) Inspect() string  { return "ERROR: " + e.Message }
+func (e *Error) Inspect() string {
+	if e.Pos.IsValid() {
+		return "ERROR: " + e.Pos.String() + ": " + e.Message
+	}
+	return "ERROR: " + e.Message
+}
+
+// maxStackTraceFrames limits how many frames StackTrace prints. Deep
+// recursion would otherwise bury the interesting frames.
+const maxStackTraceFrames = 20
+
+// StackTrace returns the call stack of the error, one frame per line.
+func (e *Error) StackTrace() string {
+	var out bytes.Buffer
+
+	for i, frame := range e.Stack {
+		if len(e.Stack) > maxStackTraceFrames && i == maxStackTraceFrames/2 {
+			skipped := len(e.Stack) - maxStackTraceFrames
+			fmt.Fprintf(&out, "    ... %d more frames\n", skipped)
+		}
+		if len(e.Stack) > maxStackTraceFrames && i >= maxStackTraceFrames/2 && i < len(e.Stack)-maxStackTraceFrames/2 {
+			continue
+		}
+		out.WriteString("    at ")
+		out.WriteString(frame.String())
+		out.WriteString("\n")
+	}
+
+	return out.String()
+}
+
+// StackFrame is a single function call recorded on an Error.
+type StackFrame struct {
+	Function  string
+	Pos       token.Position
+	Arguments []Object
+}
+
+func (f StackFrame) String() string {
+	args := make([]string, len(f.Arguments))
+	for i, arg := range f.Arguments {
+		args[i] = inspectQuoted(arg)
+	}
+	return fmt.Sprintf("%s(%s) %s", f.Function, strings.Join(args, ", "), f.Pos)
+}
+
+/**
+* Exit
+ */
+
+// Exit is returned by Eval when the program called the exit builtin. Code is
+// the exit status the program asked for.
+type Exit struct {
+	Code int64
+}
+
+func (e *Exit) Type() ObjectType { return EXIT }
+func (e *Exit) Inspect() string  { return fmt.Sprintf("exit(%d)", e.Code) }
 
 /**
 * Nothing
@@ -106,3 +398,21 @@ type Nothing struct{}
 
 func (n *Nothing) Type() ObjectType { return NOTHING }
 func (n *Nothing) Inspect() string  { return "nothing" }
+
+// inspectQuoted is like Inspect, but puts strings in quotes. It is used where
+// values are shown as part of other values.
+func inspectQuoted(obj Object) string {
+	if str, ok := obj.(*String); ok {
+		return strconv.Quote(str.Value)
+	}
+	return obj.Inspect()
+}
+
+// typeName returns the type of a value for error messages. Enum values are
+// named after their enum, so they can be told apart.
+func typeName(obj Object) string {
+	if value, ok := obj.(*EnumValue); ok {
+		return value.Enum
+	}
+	return string(obj.Type())
+}
@@ -1,137 +1,663 @@
 package lexer
 
-import "github.com/maiksch/best-lang/token"
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
 
+	"github.com/maiksch/best-lang/token"
+)
+
+// Error is a problem in the input the lexer could recover from, like a
+// comment that is never closed.
+type Error struct {
+	Pos     token.Position
+	Message string
+}
+
+func (e Error) Error() string {
+	return e.Pos.String() + ": " + e.Message
+}
+
+// Lexer splits UTF-8 encoded source code into tokens.
 type Lexer struct {
 	input    string
+	filename string
+	// position is the byte offset of the current character, next the offset
+	// of the character after it.
 	position int
+	next     int
+
+	errors []Error
+	// comments are not returned as tokens, but kept for tools like
+	// formatters that need them.
+	comments []token.Token
+
+	// interpolations are the ${...} expressions of strings that are currently
+	// being read, innermost last. resumeString is set after an interpolation
+	// ended, so the next token continues the string started at resumeStart.
+	interpolations []interpolation
+	resumeString   bool
+	resumeStart    token.Position
+
+	// Bookkeeping for token positions. scanned is the offset up to which
+	// line and column have been computed. column counts the characters
+	// between the start of the line and scanned.
+	line    int
+	column  int
+	scanned int
+}
+
+// interpolation is a ${...} expression inside of a string.
+type interpolation struct {
+	// braces counts the { opened inside of the expression, so that the } of
+	// a hash literal doesn't end the interpolation.
+	braces int
+	// start is the position of the string containing the expression.
+	start token.Position
 }
 
 func New(input string) *Lexer {
+	return NewFile("", input)
+}
+
+// NewFile creates a lexer whose token positions refer to the given file name.
+func NewFile(filename, input string) *Lexer {
 	return &Lexer{
 		input:    input,
-		position: -1,
+		filename: filename,
+		line:     1,
 	}
 }
 
+// Errors returns the problems found in the input read so far.
+func (l *Lexer) Errors() []Error {
+	return l.errors
+}
+
+// Comments returns the comments read so far as COMMENT tokens. The literal is
+// the full comment including its delimiters.
+func (l *Lexer) Comments() []token.Token {
+	return l.comments
+}
+
+// readLiteral reads an identifier or keyword. After the first letter,
+// identifiers can contain digits as well.
 func (l *Lexer) readLiteral() string {
 	position := l.position
 
-	for {
-		char := l.readChar()
-		if !l.isLetter(char) {
-			break
-		}
+	for next := l.peekChar(); l.isLetter(next) || unicode.IsDigit(next); next = l.peekChar() {
+		l.readChar()
 	}
 
-	word := l.input[position:l.position]
-
-	l.position -= 1
-
-	return word
+	return l.input[position:l.next]
 }
 
-func (l *Lexer) isLetter(char byte) bool {
-	return char >= 'a' && char <= 'z' || char >= 'A' && char <= 'Z' || char == '_'
+// isLetter reports whether the character can start an identifier. These are
+// the letters of any script and the underscore.
+func (l *Lexer) isLetter(char rune) bool {
+	return unicode.IsLetter(char) || char == '_'
 }
 
-func (l *Lexer) readNumber() string {
+// readNumber reads an integer or a float literal like 1.5 or 2e-3. A float
+// needs digits after the decimal point and after the exponent marker.
+// Integers can also be written in hex, octal or binary with the prefixes 0x,
+// 0o and 0b. Digits can be separated by underscores like in 1_000_000. The
+// parser checks that the digits are valid.
+func (l *Lexer) readNumber() (string, token.TokenType) {
 	position := l.position
+	var tokenType token.TokenType = token.INTEGER
 
-	for {
-		char := l.readChar()
-		if !l.isDigit(char) {
-			break
+	if l.input[position] == '0' && isBasePrefix(l.peekChar()) {
+		l.readChar()
+		for next := l.peekChar(); l.isLetter(next) || l.isDigit(next); next = l.peekChar() {
+			l.readChar()
 		}
+		return l.input[position:l.next], tokenType
 	}
 
-	number := l.input[position:l.position]
+	l.skipDigits()
 
-	l.position -= 1
+	if l.peekChar() == '.' && l.isDigit(l.peekCharAt(2)) {
+		l.readChar()
+		l.skipDigits()
+		tokenType = token.FLOAT
+	}
 
-	return number
+	if peek := l.peekChar(); peek == 'e' || peek == 'E' {
+		digits := 2
+		if sign := l.peekCharAt(2); sign == '+' || sign == '-' {
+			digits = 3
+		}
+		if l.isDigit(l.peekCharAt(digits)) {
+			for i := 1; i < digits; i++ {
+				l.readChar()
+			}
+			l.skipDigits()
+			tokenType = token.FLOAT
+		}
+	}
+
+	return l.input[position:l.next], tokenType
 }
 
-func (l *Lexer) isDigit(char byte) bool {
+// skipDigits skips decimal digits and the underscores separating them.
+func (l *Lexer) skipDigits() {
+	for next := l.peekChar(); l.isDigit(next) || next == '_'; next = l.peekChar() {
+		l.readChar()
+	}
+}
+
+func isBasePrefix(char rune) bool {
+	switch char {
+	case 'x', 'X', 'o', 'O', 'b', 'B':
+		return true
+	}
+	return false
+}
+
+func (l *Lexer) isDigit(char rune) bool {
 	return char >= '0' && char <= '9'
 }
 
-func (l *Lexer) isWhitespace(char byte) bool {
+func (l *Lexer) isWhitespace(char rune) bool {
 	return char == ' ' || char == '\t'
 }
 
-func (l *Lexer) readChar() byte {
-	l.position += 1
-	if l.position >= len(l.input) {
+// readChar moves to the next character and returns it. At the end of the
+// input it returns 0.
+func (l *Lexer) readChar() rune {
+	l.position = l.next
+	if l.next >= len(l.input) {
 		return 0
 	}
-	return l.input[l.position]
+	char, width := utf8.DecodeRuneInString(l.input[l.next:])
+	l.next += width
+	return char
+}
+
+// skipTo continues reading at the given offset.
+func (l *Lexer) skipTo(offset int) {
+	if offset > len(l.input) {
+		offset = len(l.input)
+	}
+	l.next = offset
+}
+
+// readStringToken reads a string, or the part of it up to the next ${. The
+// string started at start; continued is set if the part follows an
+// interpolation.
+func (l *Lexer) readStringToken(start, pos token.Position, continued bool) token.Token {
+	value, interpolated := l.readString(start)
+
+	tokenType := token.TokenType(token.STRING)
+	switch {
+	case interpolated:
+		l.interpolations = append(l.interpolations, interpolation{start: start})
+		tokenType = token.STRING_PART
+	case continued:
+		tokenType = token.STRING_END
+	}
+
+	return token.Token{Type: tokenType, Literal: value, Pos: pos}
+}
+
+// readString reads a string literal and resolves its escape sequences. The
+// current character is the opening quote, or the } of an interpolation the
+// string continues after. Strings can span lines and end at the closing
+// quote, at the next ${ or, if the closing quote is missing, at the end of
+// the input. The result reports whether the string stopped at a ${.
+func (l *Lexer) readString(start token.Position) (string, bool) {
+	var out strings.Builder
+
+	for {
+		switch ch := l.peekChar(); ch {
+		case '"':
+			l.readChar()
+			return out.String(), false
+		case '$':
+			l.readChar()
+			if l.peekChar() == '{' {
+				l.readChar()
+				return out.String(), true
+			}
+			out.WriteRune(ch)
+		case 0:
+			l.errorf(start, "String is missing closing \"")
+			return out.String(), false
+		case '\\':
+			l.readChar()
+			l.readEscape(&out)
+		default:
+			l.readChar()
+			out.WriteString(l.input[l.position:l.next])
+		}
+	}
 }
 
-func (l *Lexer) readString() string {
-	stringVal := ""
-	for l.peekChar() != '"' {
-		stringVal += string(l.readChar())
+// readRawString reads a string in backticks. It can span lines and has no
+// escape sequences. The current character is the opening backtick.
+func (l *Lexer) readRawString(pos token.Position) string {
+	start := l.next
+
+	end := strings.IndexByte(l.input[start:], '`')
+	if end < 0 {
+		l.errorf(pos, "Raw string is missing closing `")
+		l.skipTo(len(l.input))
+		return l.input[start:]
 	}
+
+	l.skipTo(start + end + 1)
+	return l.input[start : start+end]
+}
+
+// readMultilineString reads a string in triple quotes. The current character
+// is the first of the opening quotes. If the opening and the closing quotes
+// are on lines of their own, these lines are not part of the string. The
+// indentation all other lines have in common is removed, so the string can
+// be indented like the code around it:
+//
+//	text := """
+//	    first line
+//	      second line
+//	    """
+//
+// is "first line\n  second line". Escape sequences work like in other
+// strings, interpolation does not.
+func (l *Lexer) readMultilineString(pos token.Position) string {
+	l.readChar()
 	l.readChar()
-	return stringVal
+	start := l.next
+
+	end := start
+	for ; end < len(l.input) && !strings.HasPrefix(l.input[end:], `"""`); end++ {
+		if l.input[end] == '\\' {
+			end++
+		}
+	}
+	if end >= len(l.input) {
+		l.errorf(pos, "Multi-line string is missing closing \"\"\"")
+		end = len(l.input)
+	}
+
+	lines := splitLines(l.input, start, end)
+	if len(lines) > 1 && isBlank(l.input[lines[0].start:lines[0].end]) {
+		lines = lines[1:]
+	}
+	if last := lines[len(lines)-1]; len(lines) > 1 && isBlank(l.input[last.start:last.end]) {
+		lines = lines[:len(lines)-1]
+	}
+
+	indent := commonIndentation(l.input, lines)
+
+	var out strings.Builder
+	for i, line := range lines {
+		if i > 0 {
+			out.WriteByte('\n')
+		}
+		if isBlank(l.input[line.start:line.end]) {
+			continue
+		}
+
+		l.skipTo(line.start + len(indent))
+		for l.next < line.end {
+			ch := l.readChar()
+			if ch != '\\' {
+				out.WriteString(l.input[l.position:l.next])
+				continue
+			}
+			if l.next == line.end {
+				l.errorf(l.positionAt(l.position), "Escape sequence is missing at the end of the line")
+				continue
+			}
+			l.readEscape(&out)
+		}
+	}
+
+	// Continue after the closing quotes
+	l.skipTo(end + 3)
+
+	return out.String()
 }
 
-func (l *Lexer) peekChar() byte {
-	position := l.position + 1
-	if position >= len(l.input) {
+// span is a range of offsets in the input.
+type span struct {
+	start, end int
+}
+
+// splitLines splits input[start:end] into lines, without the line breaks.
+func splitLines(input string, start, end int) []span {
+	var lines []span
+	for {
+		i := strings.IndexByte(input[start:end], '\n')
+		if i < 0 {
+			return append(lines, span{start, end})
+		}
+		lines = append(lines, span{start, start + i})
+		start += i + 1
+	}
+}
+
+func isBlank(line string) bool {
+	return strings.Trim(line, " \t") == ""
+}
+
+// commonIndentation returns the leading whitespace all non-blank lines share.
+func commonIndentation(input string, lines []span) string {
+	indent := ""
+	first := true
+
+	for _, line := range lines {
+		text := input[line.start:line.end]
+		if isBlank(text) {
+			continue
+		}
+
+		leading := text[:len(text)-len(strings.TrimLeft(text, " \t"))]
+		if first {
+			indent = leading
+			first = false
+			continue
+		}
+
+		n := 0
+		for n < len(indent) && n < len(leading) && indent[n] == leading[n] {
+			n++
+		}
+		indent = indent[:n]
+	}
+
+	return indent
+}
+
+// readEscape reads the escape sequence starting at the current backslash and
+// writes the character it stands for.
+func (l *Lexer) readEscape(out *strings.Builder) {
+	pos := l.positionAt(l.position)
+
+	switch ch := l.peekChar(); ch {
+	case 'n':
+		out.WriteByte('\n')
+	case 't':
+		out.WriteByte('\t')
+	case 'r':
+		out.WriteByte('\r')
+	case '"', '\\', '$':
+		out.WriteRune(ch)
+	case 'u':
+		l.readChar()
+		l.readUnicodeEscape(pos, out)
+		return
+	case 0:
+		// Reported as unterminated string
+		return
+	case '\n':
+		// The line break stays part of the string
+		l.errorf(pos, "Unknown escape sequence \\ at the end of a line")
+		return
+	default:
+		l.errorf(pos, "Unknown escape sequence \\%c", ch)
+		out.WriteRune(ch)
+	}
+
+	l.readChar()
+}
+
+// readUnicodeEscape reads the {hex} part of a \u{hex} escape sequence.
+func (l *Lexer) readUnicodeEscape(pos token.Position, out *strings.Builder) {
+	if l.peekChar() != '{' {
+		l.errorf(pos, "Unicode escape sequence must look like \\u{1F600}")
+		return
+	}
+	l.readChar()
+
+	start := l.next
+	for isHexDigit(l.peekChar()) {
+		l.readChar()
+	}
+	digits := l.input[start:l.next]
+
+	if l.peekChar() != '}' {
+		l.errorf(pos, "Unicode escape sequence is missing closing }")
+		return
+	}
+	l.readChar()
+
+	code, err := strconv.ParseUint(digits, 16, 32)
+	if err != nil || len(digits) > 6 || !utf8.ValidRune(rune(code)) {
+		l.errorf(pos, "Invalid unicode code point \\u{%s}", digits)
+		return
+	}
+
+	out.WriteRune(rune(code))
+}
+
+func isHexDigit(char rune) bool {
+	return char >= '0' && char <= '9' || char >= 'a' && char <= 'f' || char >= 'A' && char <= 'F'
+}
+
+func (l *Lexer) errorf(pos token.Position, message string, a ...interface{}) {
+	l.errors = append(l.errors, Error{Pos: pos, Message: fmt.Sprintf(message, a...)})
+}
+
+// readLineComment reads a // comment up to the end of the line.
+func (l *Lexer) readLineComment(pos token.Position) {
+	for l.peekChar() != '\n' && l.peekChar() != 0 {
+		l.readChar()
+	}
+	l.comments = append(l.comments, token.Token{
+		Type:    token.COMMENT,
+		Literal: l.input[pos.Offset:l.next],
+		Pos:     pos,
+	})
+}
+
+// readBlockComment reads a /* */ comment. Block comments can be nested. It
+// reports whether the comment spans multiple lines.
+func (l *Lexer) readBlockComment(pos token.Position) bool {
+	l.readChar()
+	depth := 1
+	multiline := false
+
+	for depth > 0 {
+		ch := l.readChar()
+		switch {
+		case ch == 0:
+			l.errorf(pos, "Block comment is missing closing */")
+			l.skipTo(len(l.input))
+			depth = 0
+		case ch == '\n':
+			multiline = true
+		case ch == '/' && l.peekChar() == '*':
+			l.readChar()
+			depth++
+		case ch == '*' && l.peekChar() == '/':
+			l.readChar()
+			depth--
+		}
+	}
+
+	l.comments = append(l.comments, token.Token{
+		Type:    token.COMMENT,
+		Literal: l.input[pos.Offset:l.next],
+		Pos:     pos,
+	})
+	return multiline
+}
+
+func (l *Lexer) peekChar() rune {
+	return l.peekCharAt(1)
+}
+
+// peekCharAt returns the character n positions after the current one.
+func (l *Lexer) peekCharAt(n int) rune {
+	offset := l.next
+	for ; n > 1 && offset < len(l.input); n-- {
+		_, width := utf8.DecodeRuneInString(l.input[offset:])
+		offset += width
+	}
+	if offset >= len(l.input) {
 		return 0
 	}
-	return l.input[position]
+	char, _ := utf8.DecodeRuneInString(l.input[offset:])
+	return char
+}
+
+// positionAt returns the position of the given byte offset. Offsets must be
+// requested in ascending order, which holds since tokens are read front to back.
+func (l *Lexer) positionAt(offset int) token.Position {
+	if offset > len(l.input) {
+		offset = len(l.input)
+	}
+
+	for ; l.scanned < offset; l.scanned++ {
+		switch char := l.input[l.scanned]; {
+		case char == '\n':
+			l.line++
+			l.column = 0
+		case utf8.RuneStart(char):
+			l.column++
+		}
+	}
+
+	return token.Position{
+		Filename: l.filename,
+		Offset:   offset,
+		Line:     l.line,
+		Column:   l.column + 1,
+	}
 }
 
 func (l *Lexer) NextToken() token.Token {
+	if l.resumeString {
+		l.resumeString = false
+		pos := l.positionAt(l.next)
+		return l.readStringToken(l.resumeStart, pos, true)
+	}
+
 	ch := l.readChar()
 
 	for l.isWhitespace(ch) {
 		ch = l.readChar()
 	}
 
+	pos := l.positionAt(l.position)
+
 	if ch == 0 {
-		return token.Token{Type: token.EOF}
+		return token.Token{Type: token.EOF, Pos: pos}
+	}
+
+	if ch == '/' && l.peekChar() == '/' {
+		l.readLineComment(pos)
+		return l.NextToken()
+	}
+
+	if ch == '/' && l.peekChar() == '*' {
+		// A comment spanning lines ends the statement like a line break
+		if l.readBlockComment(pos) {
+			return token.Token{Type: token.NEWLINE, Pos: pos}
+		}
+		return l.NextToken()
+	}
+
+	if (ch == '&' || ch == '|') && l.peekChar() == ch {
+		// Logical operators && and ||
+		l.readChar()
+		literal := string(ch) + string(ch)
+		return token.Token{Type: token.TokenType(literal), Literal: literal, Pos: pos}
+	}
+
+	if ch == '"' && l.peekChar() == '"' && l.peekCharAt(2) == '"' {
+		return token.Token{Type: token.STRING, Literal: l.readMultilineString(pos), Pos: pos}
 	}
 
 	if ch == '"' {
-		return token.Token{Type: token.STRING, Literal: l.readString()}
+		return l.readStringToken(pos, pos, false)
+	}
+
+	if ch == '`' {
+		return token.Token{Type: token.STRING, Literal: l.readRawString(pos), Pos: pos}
+	}
+
+	if n := len(l.interpolations); n > 0 {
+		current := &l.interpolations[n-1]
+		switch ch {
+		case '{':
+			current.braces++
+		case '}':
+			if current.braces == 0 {
+				// The interpolation ends, the string goes on
+				l.resumeString = true
+				l.resumeStart = current.start
+				l.interpolations = l.interpolations[:n-1]
+				return token.Token{Type: token.INTERPOLATION_END, Literal: "}", Pos: pos}
+			}
+			current.braces--
+		}
 	}
 
 	if t, ok := token.Symbols[ch]; ok {
 		// Two symbol tokens
 		switch t {
 		case token.NEWLINE:
-			return token.Token{Type: token.NEWLINE}
+			return token.Token{Type: token.NEWLINE, Pos: pos}
 		case token.ASSIGN:
 			if peek := l.peekChar(); peek == '=' {
 				// Equal operator ==
 				l.readChar()
-				return token.Token{Type: token.EQUAL, Literal: token.EQUAL}
+				return token.Token{Type: token.EQUAL, Literal: token.EQUAL, Pos: pos}
 			}
 		case token.BANG:
 			if peek := l.peekChar(); peek == '=' {
 				l.readChar()
-				return token.Token{Type: token.NOT_EQUAL, Literal: token.NOT_EQUAL}
+				return token.Token{Type: token.NOT_EQUAL, Literal: token.NOT_EQUAL, Pos: pos}
+			}
+		case token.COLON:
+			if peek := l.peekChar(); peek == '=' {
+				l.readChar()
+				return token.Token{Type: token.DECLARE, Literal: token.DECLARE, Pos: pos}
+			}
+		case token.LT:
+			if peek := l.peekChar(); peek == '=' {
+				l.readChar()
+				return token.Token{Type: token.LT_EQUAL, Literal: token.LT_EQUAL, Pos: pos}
+			}
+		case token.GT:
+			if peek := l.peekChar(); peek == '=' {
+				l.readChar()
+				return token.Token{Type: token.GT_EQUAL, Literal: token.GT_EQUAL, Pos: pos}
+			}
+		case token.STAR:
+			if peek := l.peekChar(); peek == '*' {
+				l.readChar()
+				return token.Token{Type: token.POWER, Literal: token.POWER, Pos: pos}
+			}
+			fallthrough
+		case token.PLUS, token.MINUS, token.SLASH:
+			if peek := l.peekChar(); peek == '=' {
+				// Compound assignment like +=
+				l.readChar()
+				literal := string(ch) + "="
+				return token.Token{Type: token.TokenType(literal), Literal: literal, Pos: pos}
 			}
 		}
 
 		// One symbol tokens
-		return token.Token{Type: t, Literal: string(ch)}
+		return token.Token{Type: t, Literal: string(ch), Pos: pos}
 	}
 
 	if l.isLetter(ch) {
 		literal := l.readLiteral()
 		tokenType := token.GetWordTokenType(literal)
-		return token.Token{Type: tokenType, Literal: literal}
+		return token.Token{Type: tokenType, Literal: literal, Pos: pos}
 	}
 
 	if l.isDigit(ch) {
-		number := l.readNumber()
-		return token.Token{Type: token.INTEGER, Literal: number}
+		number, tokenType := l.readNumber()
+		return token.Token{Type: tokenType, Literal: number, Pos: pos}
 	}
 
-	return token.Token{Type: token.ILLEGAL}
+	return token.Token{Type: token.ILLEGAL, Literal: string(ch), Pos: pos}
 }
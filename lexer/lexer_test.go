@@ -13,7 +13,7 @@ type expectation struct {
 }
 
 func TestKeywords(t *testing.T) {
-	input := `if else true false fn return`
+	input := `if else true false fn return while for in break continue enum`
 
 	tests := []expectation{
 		{token.IF, "if"},
@@ -22,6 +22,12 @@ func TestKeywords(t *testing.T) {
 		{token.FALSE, "false"},
 		{token.FUNCTION, "fn"},
 		{token.RETURN, "return"},
+		{token.WHILE, "while"},
+		{token.FOR, "for"},
+		{token.IN, "in"},
+		{token.BREAK, "break"},
+		{token.CONTINUE, "continue"},
+		{token.ENUM, "enum"},
 	}
 
 	runAndExpect(t, input, tests)
@@ -40,6 +46,225 @@ func TestStringLiteral(t *testing.T) {
 	runAndExpect(t, input, tests)
 }
 
+func TestMultilinePlainString(t *testing.T) {
+	input := `"first
+second" x`
+
+	tests := []expectation{
+		{token.STRING, "first\nsecond"},
+		{token.IDENTIFIER, "x"},
+		{token.EOF, ""},
+	}
+
+	l := runAndExpect(t, input, tests)
+	if len(l.Errors()) != 0 {
+		t.Fatalf("unexpected errors: %v", l.Errors())
+	}
+}
+
+func TestStringEscapes(t *testing.T) {
+	input := `"a\nb\tc\r" "say \"hi\"" "back\\slash" "\u{48}\u{e4}\u{1F600}" "äöü"`
+
+	tests := []expectation{
+		{token.STRING, "a\nb\tc\r"},
+		{token.STRING, `say "hi"`},
+		{token.STRING, `back\slash`},
+		{token.STRING, "Hä😀"},
+		{token.STRING, "äöü"},
+		{token.EOF, ""},
+	}
+
+	l := runAndExpect(t, input, tests)
+	if len(l.Errors()) != 0 {
+		t.Fatalf("expected no errors. got %v", l.Errors())
+	}
+}
+
+func TestStringErrors(t *testing.T) {
+	tests := []struct {
+		input   string
+		literal string
+		expect  string
+	}{
+		{`"abc`, "abc", `1:1: String is missing closing "`},
+		{`"abc\`, "abc", `1:1: String is missing closing "`},
+		{`"a\qb"`, "aqb", `1:3: Unknown escape sequence \q`},
+		{"\"a\\\nb\"", "a\nb", `1:3: Unknown escape sequence \ at the end of a line`},
+		{`"\u0041"`, "0041", `1:2: Unicode escape sequence must look like \u{1F600}`},
+		{`"\u{41"`, "", `1:2: Unicode escape sequence is missing closing }`},
+		{`"\u{D800}"`, "", `1:2: Invalid unicode code point \u{D800}`},
+		{`"\u{1234567}"`, "", `1:2: Invalid unicode code point \u{1234567}`},
+	}
+
+	for _, test := range tests {
+		l := lexer.New(test.input)
+		tok := l.NextToken()
+		if tok.Type != token.STRING || tok.Literal != test.literal {
+			t.Fatalf("wrong token for %s. got %s %q", test.input, tok.Type, tok.Literal)
+		}
+		if l.NextToken().Type != token.EOF {
+			t.Fatalf("expected EOF after %s", test.input)
+		}
+
+		errors := l.Errors()
+		if len(errors) != 1 || errors[0].Error() != test.expect {
+			t.Fatalf("wrong errors for %s\n\texpected: %s\n\tgot:      %v", test.input, test.expect, errors)
+		}
+	}
+
+	// Strings can span lines, so an unterminated string runs to the end of
+	// the input
+	input := `x := "abc
+y := 1`
+
+	runAndExpect(t, input, []expectation{
+		{token.IDENTIFIER, "x"},
+		{token.DECLARE, ":="},
+		{token.STRING, "abc\ny := 1"},
+		{token.EOF, ""},
+	})
+}
+
+func TestStringInterpolation(t *testing.T) {
+	input := `"a ${x} b ${ {k: "${y}"}[k] }" "\${z}" "$"
+"open ${x`
+
+	tests := []expectation{
+		{token.STRING_PART, "a "},
+		{token.IDENTIFIER, "x"},
+		{token.INTERPOLATION_END, "}"},
+		{token.STRING_PART, " b "},
+		{token.LBRACE, "{"},
+		{token.IDENTIFIER, "k"},
+		{token.COLON, ":"},
+		{token.STRING_PART, ""},
+		{token.IDENTIFIER, "y"},
+		{token.INTERPOLATION_END, "}"},
+		{token.STRING_END, ""},
+		{token.RBRACE, "}"},
+		{token.LBRACKET, "["},
+		{token.IDENTIFIER, "k"},
+		{token.RBRACKET, "]"},
+		{token.INTERPOLATION_END, "}"},
+		{token.STRING_END, ""},
+		{token.STRING, "${z}"},
+		{token.STRING, "$"},
+		{token.NEWLINE, ""},
+		{token.STRING_PART, "open "},
+		{token.IDENTIFIER, "x"},
+		{token.EOF, ""},
+	}
+
+	runAndExpect(t, input, tests)
+
+	// Interpolations can span lines like the strings around them
+	input = "\"v=${ {\n a: x\n}[\"a\"] }\""
+	l := runAndExpect(t, input, []expectation{
+		{token.STRING_PART, "v="},
+		{token.LBRACE, "{"},
+		{token.NEWLINE, ""},
+		{token.IDENTIFIER, "a"},
+		{token.COLON, ":"},
+		{token.IDENTIFIER, "x"},
+		{token.NEWLINE, ""},
+		{token.RBRACE, "}"},
+		{token.LBRACKET, "["},
+		{token.STRING, "a"},
+		{token.RBRACKET, "]"},
+		{token.INTERPOLATION_END, "}"},
+		{token.STRING_END, ""},
+		{token.EOF, ""},
+	})
+	if len(l.Errors()) != 0 {
+		t.Fatalf("unexpected errors: %v", l.Errors())
+	}
+
+	// Parts after an interpolation start right after its }
+	l = lexer.New(`"a ${x}b"`)
+	for _, column := range []int{1, 6, 7, 8} {
+		tok := l.NextToken()
+		if tok.Pos.Column != column {
+			t.Fatalf("wrong column of %s %q. expected %d got %d", tok.Type, tok.Literal, column, tok.Pos.Column)
+		}
+	}
+}
+
+func TestMultilineString(t *testing.T) {
+	tests := []struct {
+		input  string
+		expect string
+	}{
+		{`"""one line"""`, "one line"},
+		{`""""""`, ""},
+		{"\"\"\"\n  a\n    b\n  c\n  \"\"\"", "a\n  b\nc"},
+		{"\"\"\"\n\t\ta\n\n\t\tb\n\t\"\"\"", "a\n\nb"},
+		{"\"\"\"\n    a\n  b\"\"\"", "  a\nb"},
+		{"\"\"\"first\n  second\"\"\"", "first\n  second"},
+		{"\"\"\"\n  \\t\\u{e4} \\\"\"\" \"quotes\"\n  \"\"\"", "\tä \"\"\" \"quotes\""},
+		{"\"\"\"\n  ${x}\n  \"\"\"", "${x}"},
+	}
+
+	for _, test := range tests {
+		l := lexer.New(test.input)
+		tok := l.NextToken()
+		if tok.Type != token.STRING || tok.Literal != test.expect {
+			t.Fatalf("wrong token for %q\n\texpected: STRING %q\n\tgot:      %s %q", test.input, test.expect, tok.Type, tok.Literal)
+		}
+		if tok := l.NextToken(); tok.Type != token.EOF {
+			t.Fatalf("expected EOF after %q. got %s %q", test.input, tok.Type, tok.Literal)
+		}
+		if len(l.Errors()) != 0 {
+			t.Fatalf("unexpected errors for %q: %v", test.input, l.Errors())
+		}
+	}
+
+	// The string is a single token, lines inside of it don't end statements
+	input := `x := """
+    a
+    """
+y`
+
+	l := runAndExpect(t, input, []expectation{
+		{token.IDENTIFIER, "x"},
+		{token.DECLARE, ":="},
+		{token.STRING, "a"},
+		{token.NEWLINE, ""},
+	})
+	if y := l.NextToken(); y.Pos.Line != 4 || y.Pos.Column != 1 {
+		t.Fatalf("wrong position after multi-line string. got %s", y.Pos)
+	}
+
+	l = lexer.New(`"""abc`)
+	if tok := l.NextToken(); tok.Literal != "abc" {
+		t.Fatalf("wrong literal of unterminated string. got %q", tok.Literal)
+	}
+	if errors := l.Errors(); len(errors) != 1 || errors[0].Error() != `1:1: Multi-line string is missing closing """` {
+		t.Fatalf("wrong errors. got %v", errors)
+	}
+}
+
+func TestRawString(t *testing.T) {
+	input := "`C:\\new ${x}\n  \\d+` `` x"
+
+	l := runAndExpect(t, input, []expectation{
+		{token.STRING, "C:\\new ${x}\n  \\d+"},
+		{token.STRING, ""},
+		{token.IDENTIFIER, "x"},
+		{token.EOF, ""},
+	})
+	if len(l.Errors()) != 0 {
+		t.Fatalf("unexpected errors: %v", l.Errors())
+	}
+
+	l = lexer.New("`abc\ndef")
+	if tok := l.NextToken(); tok.Literal != "abc\ndef" {
+		t.Fatalf("wrong literal of unterminated raw string. got %q", tok.Literal)
+	}
+	if errors := l.Errors(); len(errors) != 1 || errors[0].Error() != "1:1: Raw string is missing closing `" {
+		t.Fatalf("wrong errors. got %v", errors)
+	}
+}
+
 func TestBasicProgram(t *testing.T) {
 	input := `var five = 5
 var ten = 10
@@ -90,6 +315,7 @@ var result = add(five, ten)
 		{token.IDENTIFIER, "ten"},
 		{token.RPAREN, ")"},
 		{token.NEWLINE, ""},
+		{token.STRING, "test"},
 		{token.EOF, ""},
 	}
 
@@ -125,7 +351,7 @@ func TestAssignment(t *testing.T) {
 }
 
 func TestSymbols(t *testing.T) {
-	input := `+-/*()={},==<>!=
+	input := `+-/ *()={},==<>!=:.
 	`
 
 	tests := []expectation{
@@ -143,14 +369,292 @@ func TestSymbols(t *testing.T) {
 		{token.LT, "<"},
 		{token.GT, ">"},
 		{token.NOT_EQUAL, "!="},
+		{token.COLON, ":"},
+		{token.DOT, "."},
+		{token.NEWLINE, ""},
+		{token.EOF, ""},
+	}
+
+	runAndExpect(t, input, tests)
+}
+
+func TestPositions(t *testing.T) {
+	input := `var x = 5
+  "foo" x`
+
+	tests := []token.Position{
+		{Filename: "test.best", Offset: 0, Line: 1, Column: 1},
+		{Filename: "test.best", Offset: 4, Line: 1, Column: 5},
+		{Filename: "test.best", Offset: 6, Line: 1, Column: 7},
+		{Filename: "test.best", Offset: 8, Line: 1, Column: 9},
+		{Filename: "test.best", Offset: 9, Line: 1, Column: 10},
+		{Filename: "test.best", Offset: 12, Line: 2, Column: 3},
+		{Filename: "test.best", Offset: 18, Line: 2, Column: 9},
+		{Filename: "test.best", Offset: 19, Line: 2, Column: 10},
+	}
+
+	l := lexer.NewFile("test.best", input)
+
+	for _, expect := range tests {
+		tok := l.NextToken()
+		if tok.Pos != expect {
+			t.Fatalf("test failed: position of %q wrong.\n\texpected %+v\n\tgot %+v", tok.Literal, expect, tok.Pos)
+		}
+	}
+}
+
+func TestUnicode(t *testing.T) {
+	input := `größe := "äöü" + straße2 * π
+// Kommentar über Größen
+_x1 ≠ 日本`
+
+	tests := []expectation{
+		{token.IDENTIFIER, "größe"},
+		{token.DECLARE, ":="},
+		{token.STRING, "äöü"},
+		{token.PLUS, "+"},
+		{token.IDENTIFIER, "straße2"},
+		{token.STAR, "*"},
+		{token.IDENTIFIER, "π"},
 		{token.NEWLINE, ""},
+		{token.NEWLINE, ""},
+		{token.IDENTIFIER, "_x1"},
+		{token.ILLEGAL, "≠"},
+		{token.IDENTIFIER, "日本"},
+		{token.EOF, ""},
+	}
+
+	runAndExpect(t, input, tests)
+
+	// Columns count characters, offsets count bytes
+	positions := []struct {
+		line, column, offset int
+	}{
+		{1, 1, 0},
+		{1, 7, 8},
+		{1, 10, 11},
+		{1, 16, 20},
+		{1, 18, 22},
+		{1, 26, 31},
+		{1, 28, 33},
+		{1, 29, 35},
+		{2, 25, 63},
+		{3, 1, 64},
+		{3, 5, 68},
+		{3, 7, 72},
+		{3, 9, 78},
+	}
+
+	l := lexer.New(input)
+	for _, expect := range positions {
+		tok := l.NextToken()
+		if tok.Pos.Line != expect.line || tok.Pos.Column != expect.column || tok.Pos.Offset != expect.offset {
+			t.Fatalf("wrong position of %s %q.\n\texpected %d:%d offset %d\n\tgot %d:%d offset %d",
+				tok.Type, tok.Literal, expect.line, expect.column, expect.offset, tok.Pos.Line, tok.Pos.Column, tok.Pos.Offset)
+		}
+	}
+}
+
+func TestShortDeclaration(t *testing.T) {
+	input := `age := 1`
+
+	tests := []expectation{
+		{token.IDENTIFIER, "age"},
+		{token.DECLARE, ":="},
+		{token.INTEGER, "1"},
+		{token.EOF, ""},
+	}
+
+	runAndExpect(t, input, tests)
+}
+
+func TestCompoundAssignment(t *testing.T) {
+	input := `x = 1 += 2 -= 3 *= 4 /= 5 - 6`
+
+	tests := []expectation{
+		{token.IDENTIFIER, "x"},
+		{token.ASSIGN, "="},
+		{token.INTEGER, "1"},
+		{token.PLUS_ASSIGN, "+="},
+		{token.INTEGER, "2"},
+		{token.MINUS_ASSIGN, "-="},
+		{token.INTEGER, "3"},
+		{token.STAR_ASSIGN, "*="},
+		{token.INTEGER, "4"},
+		{token.SLASH_ASSIGN, "/="},
+		{token.INTEGER, "5"},
+		{token.MINUS, "-"},
+		{token.INTEGER, "6"},
+		{token.EOF, ""},
+	}
+
+	runAndExpect(t, input, tests)
+}
+
+func TestLogicalOperators(t *testing.T) {
+	input := `a && b || c & d`
+
+	tests := []expectation{
+		{token.IDENTIFIER, "a"},
+		{token.AND, "&&"},
+		{token.IDENTIFIER, "b"},
+		{token.OR, "||"},
+		{token.IDENTIFIER, "c"},
+		{token.ILLEGAL, "&"},
+		{token.IDENTIFIER, "d"},
+		{token.EOF, ""},
+	}
+
+	runAndExpect(t, input, tests)
+}
+
+func TestComparisonAndArithmeticOperators(t *testing.T) {
+	input := `a <= b >= c % d ** e *= f * g`
+
+	tests := []expectation{
+		{token.IDENTIFIER, "a"},
+		{token.LT_EQUAL, "<="},
+		{token.IDENTIFIER, "b"},
+		{token.GT_EQUAL, ">="},
+		{token.IDENTIFIER, "c"},
+		{token.PERCENT, "%"},
+		{token.IDENTIFIER, "d"},
+		{token.POWER, "**"},
+		{token.IDENTIFIER, "e"},
+		{token.STAR_ASSIGN, "*="},
+		{token.IDENTIFIER, "f"},
+		{token.STAR, "*"},
+		{token.IDENTIFIER, "g"},
+		{token.EOF, ""},
+	}
+
+	runAndExpect(t, input, tests)
+}
+
+func TestNumbers(t *testing.T) {
+	input := `1 1.5 0.25 1e3 2.5E-3 6e+2 1.x 2e`
+
+	tests := []expectation{
+		{token.INTEGER, "1"},
+		{token.FLOAT, "1.5"},
+		{token.FLOAT, "0.25"},
+		{token.FLOAT, "1e3"},
+		{token.FLOAT, "2.5E-3"},
+		{token.FLOAT, "6e+2"},
+		{token.INTEGER, "1"},
+		{token.DOT, "."},
+		{token.IDENTIFIER, "x"},
+		{token.INTEGER, "2"},
+		{token.IDENTIFIER, "e"},
+		{token.EOF, ""},
+	}
+
+	runAndExpect(t, input, tests)
+}
+
+func TestIntegerBases(t *testing.T) {
+	input := `0xFF 0Xff 0o17 0b1010 1_000_000 0x_dead_beef 1_000.5 0b102 0x 0.5`
+
+	tests := []expectation{
+		{token.INTEGER, "0xFF"},
+		{token.INTEGER, "0Xff"},
+		{token.INTEGER, "0o17"},
+		{token.INTEGER, "0b1010"},
+		{token.INTEGER, "1_000_000"},
+		{token.INTEGER, "0x_dead_beef"},
+		{token.FLOAT, "1_000.5"},
+		{token.INTEGER, "0b102"},
+		{token.INTEGER, "0x"},
+		{token.FLOAT, "0.5"},
+		{token.EOF, ""},
+	}
+
+	runAndExpect(t, input, tests)
+}
+
+func TestBrackets(t *testing.T) {
+	input := `[1, a][0]`
+
+	tests := []expectation{
+		{token.LBRACKET, "["},
+		{token.INTEGER, "1"},
+		{token.KOMMA, ","},
+		{token.IDENTIFIER, "a"},
+		{token.RBRACKET, "]"},
+		{token.LBRACKET, "["},
+		{token.INTEGER, "0"},
+		{token.RBRACKET, "]"},
 		{token.EOF, ""},
 	}
 
 	runAndExpect(t, input, tests)
 }
 
-func runAndExpect(t *testing.T, input string, tests []expectation) {
+func TestComments(t *testing.T) {
+	input := `// header
+x / y // trailing
+/* a /* nested */ comment */ x /* spans
+lines */ y`
+
+	tests := []expectation{
+		{token.NEWLINE, ""},
+		{token.IDENTIFIER, "x"},
+		{token.SLASH, "/"},
+		{token.IDENTIFIER, "y"},
+		{token.NEWLINE, ""},
+		{token.IDENTIFIER, "x"},
+		{token.NEWLINE, ""},
+		{token.IDENTIFIER, "y"},
+		{token.EOF, ""},
+	}
+
+	l := runAndExpect(t, input, tests)
+
+	comments := []struct {
+		literal      string
+		line, column int
+	}{
+		{"// header", 1, 1},
+		{"// trailing", 2, 7},
+		{"/* a /* nested */ comment */", 3, 1},
+		{"/* spans\nlines */", 3, 32},
+	}
+
+	if len(l.Comments()) != len(comments) {
+		t.Fatalf("wrong number of comments. expected %d got %d", len(comments), len(l.Comments()))
+	}
+	for i, comment := range l.Comments() {
+		expect := comments[i]
+		if comment.Type != token.COMMENT || comment.Literal != expect.literal {
+			t.Fatalf("wrong comment %d.\n\texpected %q\n\tgot %q", i, expect.literal, comment.Literal)
+		}
+		if comment.Pos.Line != expect.line || comment.Pos.Column != expect.column {
+			t.Fatalf("wrong position of comment %q. expected %d:%d got %s", comment.Literal, expect.line, expect.column, comment.Pos)
+		}
+	}
+	if len(l.Errors()) != 0 {
+		t.Fatalf("expected no errors. got %v", l.Errors())
+	}
+}
+
+func TestUnterminatedComment(t *testing.T) {
+	input := `x /* a /* b */`
+
+	l := runAndExpect(t, input, []expectation{
+		{token.IDENTIFIER, "x"},
+		{token.EOF, ""},
+	})
+
+	errors := l.Errors()
+	if len(errors) != 1 {
+		t.Fatalf("expected 1 error. got %v", errors)
+	}
+	if errors[0].Error() != "1:3: Block comment is missing closing */" {
+		t.Fatalf("wrong error. got %q", errors[0].Error())
+	}
+}
+
+func runAndExpect(t *testing.T, input string, tests []expectation) *lexer.Lexer {
 	l := lexer.New(input)
 
 	for _, test := range tests {
@@ -164,4 +668,6 @@ func runAndExpect(t *testing.T, input string, tests []expectation) {
 			t.Fatalf("test failed: token literal wrong.\n\texpected %q\n\tgot %q", test.expectedLiteral, token.Literal)
 		}
 	}
+
+	return l
 }
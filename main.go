@@ -1,39 +1,84 @@
 package main
 
 import (
+	"fmt"
 	"io"
 	"os"
 
+	"github.com/maiksch/best-lang/diagnostic"
 	"github.com/maiksch/best-lang/evaluator"
 	"github.com/maiksch/best-lang/lexer"
 	"github.com/maiksch/best-lang/parser"
 	"github.com/maiksch/best-lang/repl"
 )
 
+// Exit codes of the best command. Programs can choose their own exit code
+// with the exit builtin.
+const (
+	exitOK      = 0
+	exitRuntime = 1
+	exitUsage   = 2
+	exitParse   = 3
+	exitIO      = 4
+)
+
+const usage = "usage: best [file]"
+
 func main() {
-	args := os.Args[1:]
+	os.Exit(run(os.Args[1:]))
+}
 
+func run(args []string) int {
 	if len(args) == 0 {
 		println("Welcome to the Best Lang REPL!")
-		repl.Start(os.Stdin, os.Stdout)
+		return repl.Start(os.Stdin, os.Stdout)
+	}
+
+	if len(args) > 1 {
+		fmt.Fprintln(os.Stderr, usage)
+		return exitUsage
 	}
 
 	prog := args[0]
 
 	source, err := os.ReadFile(prog)
 	if err != nil {
-		panic(err)
+		fmt.Fprintln(os.Stderr, err)
+		return exitIO
 	}
 
 	env := evaluator.NewEnvrionment()
-	lexer := lexer.New(string(source))
+	lexer := lexer.NewFile(prog, string(source))
 	parser := parser.New(lexer)
 
+	renderer := diagnostic.NewRenderer(diagnostic.UseColor(os.Stderr))
+	renderer.AddSource(prog, string(source))
+
 	ast := parser.ParseProgram()
+	for _, warning := range parser.Warnings() {
+		renderer.Render(os.Stderr, diagnostic.FromParseWarning(warning))
+	}
+	if errors := parser.Errors(); len(errors) > 0 {
+		for _, err := range errors {
+			renderer.Render(os.Stderr, diagnostic.FromParseError(err))
+		}
+		return exitParse
+	}
 
 	result := evaluator.Eval(ast, env)
 
+	switch result := result.(type) {
+	case *evaluator.Error:
+		renderer.Render(os.Stderr, diagnostic.FromRuntimeError(result))
+		return exitRuntime
+	case *evaluator.Exit:
+		return int(result.Code)
+	}
+
 	w := os.Stdout
-	io.WriteString(w, result.Inspect())
-	io.WriteString(w, "\n")
+	if _, err := io.WriteString(w, result.Inspect()+"\n"); err != nil {
+		return exitIO
+	}
+
+	return exitOK
 }
@@ -3,6 +3,7 @@ package parser
 import (
 	"bytes"
 	"fmt"
+	"strings"
 
 	"github.com/maiksch/best-lang/token"
 )
@@ -10,6 +11,8 @@ import (
 type Node interface {
 	TokenLiteral() string
 	String() string
+	// Pos returns the position of the token the node was created from.
+	Pos() token.Position
 }
 
 type Statement interface {
@@ -34,6 +37,13 @@ func (p Program) TokenLiteral() string {
 	}
 }
 
+func (p Program) Pos() token.Position {
+	if len(p.Statements) > 0 {
+		return p.Statements[0].Pos()
+	}
+	return token.Position{}
+}
+
 func (p Program) String() string {
 	var out bytes.Buffer
 
@@ -53,6 +63,7 @@ type Identifier struct {
 
 func (i *Identifier) expressionNode()      {}
 func (i *Identifier) TokenLiteral() string { return i.Token.Literal }
+func (i *Identifier) Pos() token.Position  { return i.Token.Pos }
 func (i *Identifier) String() string       { return i.Token.Literal }
 
 // Integer Literal Expression
@@ -64,8 +75,21 @@ type IntegerLiteral struct {
 
 func (i *IntegerLiteral) expressionNode()      {}
 func (i *IntegerLiteral) TokenLiteral() string { return i.Token.Literal }
+func (i *IntegerLiteral) Pos() token.Position  { return i.Token.Pos }
 func (i *IntegerLiteral) String() string       { return i.Token.Literal }
 
+// Float Literal Expression
+
+type FloatLiteral struct {
+	Token token.Token
+	Value float64
+}
+
+func (f *FloatLiteral) expressionNode()      {}
+func (f *FloatLiteral) TokenLiteral() string { return f.Token.Literal }
+func (f *FloatLiteral) Pos() token.Position  { return f.Token.Pos }
+func (f *FloatLiteral) String() string       { return f.Token.Literal }
+
 // String Literal Expression
 
 type StringLiteral struct {
@@ -75,8 +99,41 @@ type StringLiteral struct {
 
 func (i *StringLiteral) expressionNode()      {}
 func (i *StringLiteral) TokenLiteral() string { return i.Token.Literal }
+func (i *StringLiteral) Pos() token.Position  { return i.Token.Pos }
 func (i *StringLiteral) String() string       { return i.Token.Literal }
 
+// Interpolated String Expression
+//
+// Parts alternates between the literal parts of the string, which are
+// *StringLiteral, and the embedded expressions. "a ${x} b" has the parts
+// "a ", x and " b".
+
+type InterpolatedString struct {
+	Token token.Token
+	Parts []Expression
+}
+
+func (i *InterpolatedString) expressionNode()      {}
+func (i *InterpolatedString) TokenLiteral() string { return i.Token.Literal }
+func (i *InterpolatedString) Pos() token.Position  { return i.Token.Pos }
+func (i *InterpolatedString) String() string {
+	var out bytes.Buffer
+
+	out.WriteString(`"`)
+	for _, part := range i.Parts {
+		if literal, ok := part.(*StringLiteral); ok {
+			out.WriteString(literal.Value)
+			continue
+		}
+		out.WriteString("${")
+		out.WriteString(part.String())
+		out.WriteString("}")
+	}
+	out.WriteString(`"`)
+
+	return out.String()
+}
+
 // Boolean Literal Expression
 
 type BooleanLiteral struct {
@@ -86,6 +143,7 @@ type BooleanLiteral struct {
 
 func (b *BooleanLiteral) expressionNode()      {}
 func (b *BooleanLiteral) TokenLiteral() string { return b.Token.Literal }
+func (b *BooleanLiteral) Pos() token.Position  { return b.Token.Pos }
 func (b *BooleanLiteral) String() string {
 	return fmt.Sprint(b.Value)
 }
@@ -99,6 +157,7 @@ type PrefixExpression struct {
 
 func (p *PrefixExpression) expressionNode()      {}
 func (p *PrefixExpression) TokenLiteral() string { return p.Token.Literal }
+func (p *PrefixExpression) Pos() token.Position  { return p.Token.Pos }
 func (p *PrefixExpression) String() string {
 	var out bytes.Buffer
 
@@ -121,6 +180,7 @@ type InfixExpression struct {
 
 func (i *InfixExpression) expressionNode()      {}
 func (i *InfixExpression) TokenLiteral() string { return i.Token.Literal }
+func (i *InfixExpression) Pos() token.Position  { return i.Token.Pos }
 func (i *InfixExpression) String() string {
 	var out bytes.Buffer
 
@@ -144,6 +204,7 @@ type IfExpression struct {
 
 func (i *IfExpression) expressionNode()      {}
 func (i *IfExpression) TokenLiteral() string { return i.Token.Literal }
+func (i *IfExpression) Pos() token.Position  { return i.Token.Pos }
 func (i *IfExpression) String() string {
 	var out bytes.Buffer
 
@@ -162,27 +223,49 @@ func (i *IfExpression) String() string {
 }
 
 // Function Literal
+//
+// Parameters and the return value can be annotated with a type name, like in
+// fn(a: int) int { ... }. The annotations are not checked yet.
 
 type FunctionLiteral struct {
 	Token      token.Token
 	Parameters []*Identifier
-	Body       *BlockStatement
+	// ParameterTypes holds the type annotation of each parameter, or nil
+	// for parameters without one.
+	ParameterTypes []*Identifier
+	ReturnType     *Identifier
+	Body           *BlockStatement
 }
 
 func (f *FunctionLiteral) expressionNode()      {}
 func (f *FunctionLiteral) TokenLiteral() string { return f.Token.Literal }
+func (f *FunctionLiteral) Pos() token.Position  { return f.Token.Pos }
 func (f *FunctionLiteral) String() string {
+	return "fn" + f.signature()
+}
+
+func (f *FunctionLiteral) signature() string {
 	var out bytes.Buffer
 
-	out.WriteString("fn(")
+	out.WriteString("(")
 	for i, param := range f.Parameters {
 		if i != 0 {
 			out.WriteString(", ")
 		}
 		out.WriteString(param.String())
+		if i < len(f.ParameterTypes) && f.ParameterTypes[i] != nil {
+			out.WriteString(": ")
+			out.WriteString(f.ParameterTypes[i].String())
+		}
 	}
 	out.WriteString(")")
 
+	if f.ReturnType != nil {
+		out.WriteString(" ")
+		out.WriteString(f.ReturnType.String())
+		out.WriteString(" ")
+	}
+
 	out.WriteString("{")
 	out.WriteString(f.Body.String())
 	out.WriteString("}")
@@ -190,14 +273,18 @@ func (f *FunctionLiteral) String() string {
 	return out.String()
 }
 
+// Token is the opening ( and Close the closing ) of the arguments. Calls are
+// positioned at the called expression.
 type FunctionCall struct {
 	Token     token.Token
 	Function  Expression
 	Arguments []Expression
+	Close     token.Token
 }
 
 func (f *FunctionCall) expressionNode()      {}
 func (f *FunctionCall) TokenLiteral() string { return f.Token.Literal }
+func (f *FunctionCall) Pos() token.Position  { return f.Function.Pos() }
 func (f *FunctionCall) String() string {
 	var out bytes.Buffer
 
@@ -214,6 +301,104 @@ func (f *FunctionCall) String() string {
 	return out.String()
 }
 
+// Array Literal
+
+type ArrayLiteral struct {
+	Token    token.Token
+	Elements []Expression
+	Close    token.Token
+}
+
+func (a *ArrayLiteral) expressionNode()      {}
+func (a *ArrayLiteral) TokenLiteral() string { return a.Token.Literal }
+func (a *ArrayLiteral) Pos() token.Position  { return a.Token.Pos }
+func (a *ArrayLiteral) String() string {
+	var out bytes.Buffer
+
+	out.WriteString("[")
+	for i, element := range a.Elements {
+		if i != 0 {
+			out.WriteString(", ")
+		}
+		out.WriteString(element.String())
+	}
+	out.WriteString("]")
+
+	return out.String()
+}
+
+// Hash Literal
+
+type HashLiteral struct {
+	Token  token.Token
+	Keys   []Expression
+	Values []Expression
+	Close  token.Token
+}
+
+func (h *HashLiteral) expressionNode()      {}
+func (h *HashLiteral) TokenLiteral() string { return h.Token.Literal }
+func (h *HashLiteral) Pos() token.Position  { return h.Token.Pos }
+func (h *HashLiteral) String() string {
+	var out bytes.Buffer
+
+	out.WriteString("{")
+	for i, key := range h.Keys {
+		if i != 0 {
+			out.WriteString(", ")
+		}
+		out.WriteString(key.String())
+		out.WriteString(": ")
+		out.WriteString(h.Values[i].String())
+	}
+	out.WriteString("}")
+
+	return out.String()
+}
+
+// Index Expression
+
+type IndexExpression struct {
+	Token token.Token
+	Left  Expression
+	Index Expression
+	Close token.Token
+}
+
+func (i *IndexExpression) expressionNode()      {}
+func (i *IndexExpression) TokenLiteral() string { return i.Token.Literal }
+func (i *IndexExpression) Pos() token.Position  { return i.Token.Pos }
+func (i *IndexExpression) String() string {
+	var out bytes.Buffer
+
+	out.WriteString("(")
+	out.WriteString(i.Left.String())
+	out.WriteString("[")
+	out.WriteString(i.Index.String())
+	out.WriteString("])")
+
+	return out.String()
+}
+
+// Member Expression
+//
+// Member expressions name a part of a value, like the variant Circle of the
+// enum Shape in Shape.Circle. Token is the dot, the expression is positioned
+// at its left side.
+
+type MemberExpression struct {
+	Token  token.Token
+	Left   Expression
+	Member *Identifier
+}
+
+func (m *MemberExpression) expressionNode()      {}
+func (m *MemberExpression) TokenLiteral() string { return m.Token.Literal }
+func (m *MemberExpression) Pos() token.Position  { return m.Left.Pos() }
+func (m *MemberExpression) String() string {
+	return "(" + m.Left.String() + "." + m.Member.String() + ")"
+}
+
 // Block Statement
 
 type BlockStatement struct {
@@ -223,6 +408,7 @@ type BlockStatement struct {
 
 func (b *BlockStatement) statementNode()       {}
 func (b *BlockStatement) TokenLiteral() string { return b.Token.Literal }
+func (b *BlockStatement) Pos() token.Position  { return b.Token.Pos }
 func (b *BlockStatement) String() string {
 	var out bytes.Buffer
 	for _, stmt := range b.Statements {
@@ -232,6 +418,9 @@ func (b *BlockStatement) String() string {
 }
 
 // Declare Statement
+//
+// Declarations are written either as var x = 1 or as x := 1. Token is the
+// var keyword or the := operator.
 
 type DeclareStatement struct {
 	Token      token.Token
@@ -241,12 +430,18 @@ type DeclareStatement struct {
 
 func (d *DeclareStatement) statementNode()       {}
 func (d *DeclareStatement) TokenLiteral() string { return d.Token.Literal }
+func (d *DeclareStatement) Pos() token.Position  { return d.Token.Pos }
 func (d *DeclareStatement) String() string {
 	var out bytes.Buffer
 
-	out.WriteString("var ")
-	out.WriteString(d.Name.String())
-	out.WriteString(" = ")
+	if d.Token.Type == token.DECLARE {
+		out.WriteString(d.Name.String())
+		out.WriteString(" := ")
+	} else {
+		out.WriteString("var ")
+		out.WriteString(d.Name.String())
+		out.WriteString(" = ")
+	}
 
 	if d.Expression != nil {
 		out.WriteString(d.Expression.String())
@@ -255,6 +450,168 @@ func (d *DeclareStatement) String() string {
 	return out.String()
 }
 
+// Assign Statement
+//
+// Token is the assignment operator, either = or a compound one like +=.
+
+type AssignStatement struct {
+	Token      token.Token
+	Name       *Identifier
+	Expression Expression
+}
+
+func (a *AssignStatement) statementNode()       {}
+func (a *AssignStatement) TokenLiteral() string { return a.Token.Literal }
+func (a *AssignStatement) Pos() token.Position  { return a.Token.Pos }
+func (a *AssignStatement) String() string {
+	var out bytes.Buffer
+
+	out.WriteString(a.Name.String())
+	out.WriteString(" " + a.Token.Literal + " ")
+	if a.Expression != nil {
+		out.WriteString(a.Expression.String())
+	}
+
+	return out.String()
+}
+
+// Operator returns the infix operator of a compound assignment, like + for
+// +=, or an empty string for a plain assignment.
+func (a *AssignStatement) Operator() string {
+	return strings.TrimSuffix(a.Token.Literal, "=")
+}
+
+// Function Declaration
+
+type FunctionDeclaration struct {
+	Token    token.Token
+	Name     *Identifier
+	Function *FunctionLiteral
+}
+
+func (f *FunctionDeclaration) statementNode()       {}
+func (f *FunctionDeclaration) TokenLiteral() string { return f.Token.Literal }
+func (f *FunctionDeclaration) Pos() token.Position  { return f.Token.Pos }
+func (f *FunctionDeclaration) String() string {
+	return "fn " + f.Name.String() + f.Function.signature()
+}
+
+// Enum Declaration
+//
+// enum Shape { Circle(r), Rect(w, h), Empty } declares the variants of an
+// enum. They are reached through the name of the enum, like Shape.Empty.
+// Variants with fields construct values carrying a payload, like
+// Shape.Circle(2), variants without fields are values themselves.
+
+type EnumDeclaration struct {
+	Token    token.Token
+	Name     *Identifier
+	Variants []*EnumVariant
+}
+
+func (e *EnumDeclaration) statementNode()       {}
+func (e *EnumDeclaration) TokenLiteral() string { return e.Token.Literal }
+func (e *EnumDeclaration) Pos() token.Position  { return e.Token.Pos }
+func (e *EnumDeclaration) String() string {
+	variants := make([]string, len(e.Variants))
+	for i, variant := range e.Variants {
+		variants[i] = variant.String()
+	}
+	return "enum " + e.Name.String() + " { " + strings.Join(variants, ", ") + " }"
+}
+
+type EnumVariant struct {
+	Name   *Identifier
+	Fields []*Identifier
+}
+
+func (v *EnumVariant) TokenLiteral() string { return v.Name.TokenLiteral() }
+func (v *EnumVariant) Pos() token.Position  { return v.Name.Pos() }
+func (v *EnumVariant) String() string {
+	if len(v.Fields) == 0 {
+		return v.Name.String()
+	}
+	fields := make([]string, len(v.Fields))
+	for i, field := range v.Fields {
+		fields[i] = field.String()
+	}
+	return v.Name.String() + "(" + strings.Join(fields, ", ") + ")"
+}
+
+// While Statement
+
+type WhileStatement struct {
+	Token     token.Token
+	Condition Expression
+	Body      *BlockStatement
+}
+
+func (w *WhileStatement) statementNode()       {}
+func (w *WhileStatement) TokenLiteral() string { return w.Token.Literal }
+func (w *WhileStatement) Pos() token.Position  { return w.Token.Pos }
+func (w *WhileStatement) String() string {
+	var out bytes.Buffer
+
+	out.WriteString("while ")
+	out.WriteString(w.Condition.String())
+	out.WriteString(" { ")
+	out.WriteString(w.Body.String())
+	out.WriteString(" }")
+
+	return out.String()
+}
+
+// For Statement
+//
+// For loops run their body once for every element of an array, every key of
+// a hash or every character of a string: for x in [1, 2, 3] { ... }
+
+type ForStatement struct {
+	Token    token.Token
+	Variable *Identifier
+	Iterable Expression
+	Body     *BlockStatement
+}
+
+func (f *ForStatement) statementNode()       {}
+func (f *ForStatement) TokenLiteral() string { return f.Token.Literal }
+func (f *ForStatement) Pos() token.Position  { return f.Token.Pos }
+func (f *ForStatement) String() string {
+	var out bytes.Buffer
+
+	out.WriteString("for ")
+	out.WriteString(f.Variable.String())
+	out.WriteString(" in ")
+	out.WriteString(f.Iterable.String())
+	out.WriteString(" { ")
+	out.WriteString(f.Body.String())
+	out.WriteString(" }")
+
+	return out.String()
+}
+
+// Break Statement
+
+type BreakStatement struct {
+	Token token.Token
+}
+
+func (b *BreakStatement) statementNode()       {}
+func (b *BreakStatement) TokenLiteral() string { return b.Token.Literal }
+func (b *BreakStatement) Pos() token.Position  { return b.Token.Pos }
+func (b *BreakStatement) String() string       { return b.Token.Literal }
+
+// Continue Statement
+
+type ContinueStatement struct {
+	Token token.Token
+}
+
+func (c *ContinueStatement) statementNode()       {}
+func (c *ContinueStatement) TokenLiteral() string { return c.Token.Literal }
+func (c *ContinueStatement) Pos() token.Position  { return c.Token.Pos }
+func (c *ContinueStatement) String() string       { return c.Token.Literal }
+
 // Return Statement
 
 type ReturnStatement struct {
@@ -264,6 +621,7 @@ type ReturnStatement struct {
 
 func (r *ReturnStatement) statementNode()       {}
 func (r *ReturnStatement) TokenLiteral() string { return r.Token.Literal }
+func (r *ReturnStatement) Pos() token.Position  { return r.Token.Pos }
 func (r *ReturnStatement) String() string {
 	var out bytes.Buffer
 
@@ -286,6 +644,7 @@ type ExpressionStatement struct {
 
 func (e *ExpressionStatement) statementNode()       {}
 func (e *ExpressionStatement) TokenLiteral() string { return e.Token.Literal }
+func (e *ExpressionStatement) Pos() token.Position  { return e.Token.Pos }
 func (e *ExpressionStatement) String() string {
 	if e.Value != nil {
 		return e.Value.String()
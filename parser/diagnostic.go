@@ -0,0 +1,36 @@
+package parser
+
+import "github.com/maiksch/best-lang/token"
+
+// Diagnostic describes a syntax error found while parsing.
+type Diagnostic struct {
+	Message string
+	// Expected is the token type the parser was looking for. It is empty if
+	// the parser did not expect a specific token.
+	Expected token.TokenType
+	// Found is the token the parser encountered instead.
+	Found token.Token
+	// Labels point at other source locations that help explaining the error.
+	Labels []Label
+}
+
+// Label is a secondary source location attached to a diagnostic, like the
+// opening bracket of a block that is never closed.
+type Label struct {
+	Pos token.Position
+	// Length is the number of columns the label spans. Zero means a single
+	// column.
+	Length  int
+	Message string
+}
+
+func (d *Diagnostic) Pos() token.Position { return d.Found.Pos }
+
+func (d *Diagnostic) Error() string {
+	return d.Found.Pos.String() + ": " + d.Message
+}
+
+func (d *Diagnostic) label(pos token.Position, message string) *Diagnostic {
+	d.Labels = append(d.Labels, Label{Pos: pos, Message: message})
+	return d
+}
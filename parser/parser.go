@@ -1,7 +1,8 @@
 package parser
 
 import (
-	"log"
+	"errors"
+	"fmt"
 	"strconv"
 
 	"github.com/maiksch/best-lang/lexer"
@@ -11,24 +12,36 @@ import (
 const (
 	_ int = iota
 	LOWEST
+	OR          // ||
+	AND         // &&
 	EQUALS      // ==
 	LESSGREATER // <
 	SUM         // +
 	PRODUCT     // *
 	PREFIX      // -x or !x
+	POWER       // **, binds stronger than prefix operators so -2 ** 2 is -4
 	CALL        // myFn()
+	INDEX       // array[index]
 )
 
 var precedences = map[token.TokenType]int{
+	token.OR:        OR,
+	token.AND:       AND,
 	token.EQUAL:     EQUALS,
 	token.NOT_EQUAL: EQUALS,
 	token.LT:        LESSGREATER,
 	token.GT:        LESSGREATER,
+	token.LT_EQUAL:  LESSGREATER,
+	token.GT_EQUAL:  LESSGREATER,
 	token.PLUS:      SUM,
 	token.MINUS:     SUM,
 	token.STAR:      PRODUCT,
 	token.SLASH:     PRODUCT,
+	token.PERCENT:   PRODUCT,
+	token.POWER:     POWER,
 	token.LPAREN:    CALL,
+	token.LBRACKET:  INDEX,
+	token.DOT:       INDEX,
 }
 
 type (
@@ -42,6 +55,15 @@ type Parser struct {
 	token     token.Token
 	peekToken token.Token
 
+	errors   []*Diagnostic
+	warnings []*Diagnostic
+	scopes   []*scope
+	// lexerErrors is the number of lexer errors already added to errors.
+	lexerErrors int
+	// loopDepth is the number of loops around the current statement inside
+	// the current function.
+	loopDepth int
+
 	prefixParseFns map[token.TokenType]prefixParseFn
 	infixParseFns  map[token.TokenType]infixParseFn
 }
@@ -52,7 +74,9 @@ func New(l *lexer.Lexer) *Parser {
 	p.prefixParseFns = make(map[token.TokenType]prefixParseFn)
 	p.registerPrefix(token.IDENTIFIER, p.parseIdentifier)
 	p.registerPrefix(token.INTEGER, p.parseIntegerLiteral)
+	p.registerPrefix(token.FLOAT, p.parseFloatLiteral)
 	p.registerPrefix(token.STRING, p.parseStringLiteral)
+	p.registerPrefix(token.STRING_PART, p.parseInterpolatedString)
 	p.registerPrefix(token.TRUE, p.parseBooleanLiteral)
 	p.registerPrefix(token.FALSE, p.parseBooleanLiteral)
 	p.registerPrefix(token.MINUS, p.parsePrefixExpression)
@@ -60,17 +84,29 @@ func New(l *lexer.Lexer) *Parser {
 	p.registerPrefix(token.LPAREN, p.parseGroupedExpression)
 	p.registerPrefix(token.IF, p.parseIfExpression)
 	p.registerPrefix(token.FUNCTION, p.parseFunctionExpression)
+	p.registerPrefix(token.LBRACKET, p.parseArrayLiteral)
+	p.registerPrefix(token.LBRACE, p.parseHashLiteral)
 
 	p.infixParseFns = make(map[token.TokenType]infixParseFn)
+	p.registerInfix(token.OR, p.parseInfixExpression)
+	p.registerInfix(token.AND, p.parseInfixExpression)
 	p.registerInfix(token.EQUAL, p.parseInfixExpression)
 	p.registerInfix(token.NOT_EQUAL, p.parseInfixExpression)
 	p.registerInfix(token.LT, p.parseInfixExpression)
 	p.registerInfix(token.GT, p.parseInfixExpression)
+	p.registerInfix(token.LT_EQUAL, p.parseInfixExpression)
+	p.registerInfix(token.GT_EQUAL, p.parseInfixExpression)
 	p.registerInfix(token.PLUS, p.parseInfixExpression)
 	p.registerInfix(token.MINUS, p.parseInfixExpression)
 	p.registerInfix(token.STAR, p.parseInfixExpression)
 	p.registerInfix(token.SLASH, p.parseInfixExpression)
+	p.registerInfix(token.PERCENT, p.parseInfixExpression)
+	p.registerInfix(token.POWER, p.parseInfixExpression)
 	p.registerInfix(token.LPAREN, p.parseFunctionCall)
+	p.registerInfix(token.LBRACKET, p.parseIndexExpression)
+	p.registerInfix(token.DOT, p.parseMemberExpression)
+
+	p.openScope()
 
 	// Read two tokens, so token and peekToken are both set
 	p.nextToken()
@@ -91,93 +127,301 @@ func (p *Parser) nextToken() {
 	p.token = p.peekToken
 	p.peekToken = p.lexer.NextToken()
 	// log.Printf("%s %s\n", p.token.Type, p.token.Literal)
+
+	// Problems the lexer recovered from are syntax errors as well
+	for _, err := range p.lexer.Errors()[p.lexerErrors:] {
+		p.errors = append(p.errors, &Diagnostic{
+			Message: "invalid syntax. " + err.Message,
+			Found:   token.Token{Type: token.ILLEGAL, Pos: err.Pos},
+		})
+	}
+	p.lexerErrors = len(p.lexer.Errors())
 }
 
+// Errors returns the syntax errors found by ParseProgram.
+func (p *Parser) Errors() []*Diagnostic {
+	return p.errors
+}
+
+// Warnings returns problems found by ParseProgram that don't prevent the
+// program from running, like calls with the wrong number of arguments.
+func (p *Parser) Warnings() []*Diagnostic {
+	return p.warnings
+}
+
+// ParseProgram parses the whole input. After a syntax error the parser skips
+// to the next statement and continues, so a single run reports every error.
+// The returned program always contains the statements that could be parsed.
+// Check Errors before evaluating it.
 func (p *Parser) ParseProgram() *Program {
 	program := &Program{}
 
+	p.skipNewlines()
+
 	for p.token.Type != token.EOF {
 		statement := p.parseStatement()
+		if statement == nil {
+			p.synchronize()
+			if p.token.Type == token.RBRACE {
+				// A } without a matching { can't end anything on the top level
+				p.nextToken()
+			}
+			p.skipNewlines()
+			continue
+		}
 
 		program.Statements = append(program.Statements, statement)
 
 		p.nextToken()
+		p.skipNewlines()
 	}
 
+	p.finishArityChecks()
+
 	return program
 }
 
+// parseStatement parses the statement starting at the current token. On
+// success the current token is the last token of the statement, on a syntax
+// error nil is returned.
 func (p *Parser) parseStatement() Statement {
-	for p.token.Type == token.NEWLINE {
-		p.nextToken()
-	}
-
 	switch p.token.Type {
 	case token.VARIABLE:
 		return p.parseDeclarationStmt()
+	case token.IDENTIFIER:
+		switch p.peekToken.Type {
+		case token.DECLARE:
+			return p.parseShortDeclarationStmt()
+		case token.ASSIGN, token.PLUS_ASSIGN, token.MINUS_ASSIGN, token.STAR_ASSIGN, token.SLASH_ASSIGN:
+			return p.parseAssignStmt()
+		}
+		return p.parseExpressionStmt()
+	case token.FUNCTION:
+		if p.peekToken.Type == token.IDENTIFIER {
+			return p.parseFunctionDeclaration()
+		}
+		return p.parseExpressionStmt()
 	case token.RETURN:
 		return p.parseReturnStmt()
+	case token.WHILE:
+		return p.parseWhileStmt()
+	case token.FOR:
+		return p.parseForStmt()
+	case token.BREAK, token.CONTINUE:
+		return p.parseLoopControlStmt()
+	case token.ENUM:
+		return p.parseEnumDeclaration()
 	default:
 		return p.parseExpressionStmt()
 	}
 }
 
+// parseBlockStatement parses the statements following the opening { at the
+// current token. On success the current token is the closing }.
 func (p *Parser) parseBlockStatement() *BlockStatement {
 	blockStmt := &BlockStatement{
 		Token: p.token,
 	}
 
-	for !p.isPeekToken(token.EOF) &&
-		!p.isPeekToken(token.RBRACE) &&
-		!(p.token.Type == token.RBRACE && p.peekToken.Type == token.NEWLINE) {
-		p.nextToken()
+	p.nextToken()
+	p.skipNewlines()
+
+	for p.token.Type != token.RBRACE {
+		if p.token.Type == token.EOF {
+			p.errorf(token.RBRACE, p.token, "invalid syntax. Block is missing closing }").
+				label(blockStmt.Token.Pos, "block opened here")
+			return nil
+		}
 
 		stmt := p.parseStatement()
+		if stmt == nil {
+			p.synchronize()
+			p.skipNewlines()
+			continue
+		}
 
 		blockStmt.Statements = append(blockStmt.Statements, stmt)
+
+		p.nextToken()
+		p.skipNewlines()
 	}
 
 	return blockStmt
 }
 
-func (p *Parser) parseDeclarationStmt() *DeclareStatement {
+func (p *Parser) parseDeclarationStmt() Statement {
 	s := &DeclareStatement{Token: p.token}
 
-	p.assertNextToken(token.IDENTIFIER)
+	if !p.assertNextToken(token.IDENTIFIER) {
+		return nil
+	}
 
 	s.Name = &Identifier{Token: p.token, Value: p.token.Literal}
 
-	p.assertNextToken(token.ASSIGN)
+	if !p.assertNextToken(token.ASSIGN) {
+		return nil
+	}
 
 	p.nextToken()
 
 	s.Expression = p.parseExpression(LOWEST)
+	if s.Expression == nil || !p.assertEnd() {
+		return nil
+	}
 
-	p.assertEnd()
+	p.declare(s.Name.Value, s.Expression)
 
 	return s
 }
 
-func (p *Parser) parseReturnStmt() *ReturnStatement {
+func (p *Parser) parseShortDeclarationStmt() Statement {
+	name := &Identifier{Token: p.token, Value: p.token.Literal}
+
+	p.nextToken()
+
+	s := &DeclareStatement{Token: p.token, Name: name}
+
+	p.nextToken()
+
+	s.Expression = p.parseExpression(LOWEST)
+	if s.Expression == nil || !p.assertEnd() {
+		return nil
+	}
+
+	p.declare(s.Name.Value, s.Expression)
+
+	return s
+}
+
+func (p *Parser) parseAssignStmt() Statement {
+	name := &Identifier{Token: p.token, Value: p.token.Literal}
+
+	p.nextToken()
+
+	s := &AssignStatement{Token: p.token, Name: name}
+
+	p.nextToken()
+
+	s.Expression = p.parseExpression(LOWEST)
+	if s.Expression == nil || !p.assertEnd() {
+		return nil
+	}
+
+	p.assign(s.Name.Value)
+
+	return s
+}
+
+func (p *Parser) parseReturnStmt() Statement {
 	s := &ReturnStatement{Token: p.token}
 
 	p.nextToken()
 
 	s.Expression = p.parseExpression(LOWEST)
+	if s.Expression == nil || !p.assertEnd() {
+		return nil
+	}
 
-	p.assertEnd()
+	return s
+}
+
+func (p *Parser) parseWhileStmt() Statement {
+	s := &WhileStatement{Token: p.token}
+
+	p.nextToken()
+
+	s.Condition = p.parseExpression(LOWEST)
+	if s.Condition == nil {
+		return nil
+	}
+
+	if !p.isPeekToken(token.LBRACE) {
+		p.errorf(token.LBRACE, p.peekToken, "invalid syntax. While loop is missing opening {")
+		return nil
+	}
+
+	p.openScope()
+	s.Body = p.parseLoopBody()
+	p.closeScope()
+
+	if s.Body == nil || !p.assertEnd() {
+		return nil
+	}
 
 	return s
 }
 
-func (p *Parser) parseExpressionStmt() *ExpressionStatement {
-	stmt := &ExpressionStatement{
-		Token: p.token,
-		Value: p.parseExpression(LOWEST),
+func (p *Parser) parseForStmt() Statement {
+	s := &ForStatement{Token: p.token}
+
+	if !p.assertNextToken(token.IDENTIFIER) {
+		return nil
 	}
 
-	if p.peekToken.Type == token.NEWLINE {
-		p.nextToken()
+	s.Variable = &Identifier{Token: p.token, Value: p.token.Literal}
+
+	if !p.assertNextToken(token.IN) {
+		return nil
+	}
+
+	p.nextToken()
+
+	s.Iterable = p.parseExpression(LOWEST)
+	if s.Iterable == nil {
+		return nil
+	}
+
+	if !p.isPeekToken(token.LBRACE) {
+		p.errorf(token.LBRACE, p.peekToken, "invalid syntax. For loop is missing opening {")
+		return nil
+	}
+
+	p.openScope()
+	p.declare(s.Variable.Value, nil)
+
+	s.Body = p.parseLoopBody()
+
+	p.closeScope()
+
+	if s.Body == nil || !p.assertEnd() {
+		return nil
+	}
+
+	return s
+}
+
+func (p *Parser) parseLoopBody() *BlockStatement {
+	p.loopDepth++
+	body := p.parseBlockStatement()
+	p.loopDepth--
+
+	return body
+}
+
+func (p *Parser) parseLoopControlStmt() Statement {
+	tok := p.token
+
+	if p.loopDepth == 0 {
+		p.errorf("", tok, "invalid syntax. %s is only allowed inside of a loop", tok.Literal)
+		return nil
+	}
+
+	if !p.assertEnd() {
+		return nil
+	}
+
+	if tok.Type == token.BREAK {
+		return &BreakStatement{Token: tok}
+	}
+	return &ContinueStatement{Token: tok}
+}
+
+func (p *Parser) parseExpressionStmt() Statement {
+	stmt := &ExpressionStatement{Token: p.token}
+
+	stmt.Value = p.parseExpression(LOWEST)
+	if stmt.Value == nil || !p.assertEnd() {
+		return nil
 	}
 
 	return stmt
@@ -186,18 +430,19 @@ func (p *Parser) parseExpressionStmt() *ExpressionStatement {
 func (p *Parser) parseExpression(precedence int) Expression {
 	prefix, ok := p.prefixParseFns[p.token.Type]
 	if !ok {
-		log.Printf("No prefix fn found for token %q", p.token.Literal)
+		p.errorf("", p.token, "invalid syntax. Unexpected %q", p.token.Type)
 		return nil
 	}
 
 	exp := prefix()
 
-	for p.peekToken.Type != token.NEWLINE && precedence < p.peekPrecedence() {
+	for exp != nil && p.peekToken.Type != token.NEWLINE && precedence < p.peekPrecedence() {
 		p.nextToken()
 
 		infix, ok := p.infixParseFns[p.token.Type]
 		if !ok {
-			log.Panicf("No infix fn found for token %q", p.token.Literal)
+			p.errorf("", p.token, "invalid syntax. Unexpected %q", p.token.Type)
+			return nil
 		}
 
 		exp = infix(exp)
@@ -221,9 +466,20 @@ func (p *Parser) parseIdentifier() Expression {
 }
 
 func (p *Parser) parseIntegerLiteral() Expression {
+	// strconv would read 010 as octal, which is written 0o10 here
+	if literal := p.token.Literal; len(literal) > 1 && literal[0] == '0' && (isDigit(literal[1]) || literal[1] == '_') {
+		p.errorf("", p.token, "invalid syntax. Integer literal %s has a leading zero. Octal numbers are written like 0o17", literal)
+		return nil
+	}
+
 	value, err := strconv.ParseInt(p.token.Literal, 0, 64)
+	if errors.Is(err, strconv.ErrRange) {
+		p.errorf("", p.token, "invalid syntax. Integer literal %s does not fit into 64 bits", p.token.Literal)
+		return nil
+	}
 	if err != nil {
-		log.Fatal(err)
+		p.errorf("", p.token, "invalid syntax. Invalid integer literal %s", p.token.Literal)
+		return nil
 	}
 	return &IntegerLiteral{
 		Token: p.token,
@@ -231,6 +487,22 @@ func (p *Parser) parseIntegerLiteral() Expression {
 	}
 }
 
+func (p *Parser) parseFloatLiteral() Expression {
+	value, err := strconv.ParseFloat(p.token.Literal, 64)
+	if errors.Is(err, strconv.ErrRange) {
+		p.errorf("", p.token, "invalid syntax. Float literal %s does not fit into 64 bits", p.token.Literal)
+		return nil
+	}
+	if err != nil {
+		p.errorf("", p.token, "invalid syntax. Invalid float literal %s", p.token.Literal)
+		return nil
+	}
+	return &FloatLiteral{
+		Token: p.token,
+		Value: value,
+	}
+}
+
 func (p *Parser) parseStringLiteral() Expression {
 	return &StringLiteral{
 		Token: p.token,
@@ -238,6 +510,40 @@ func (p *Parser) parseStringLiteral() Expression {
 	}
 }
 
+// parseInterpolatedString parses a string with embedded expressions. The
+// current token is the STRING_PART before the first expression.
+func (p *Parser) parseInterpolatedString() Expression {
+	expr := &InterpolatedString{Token: p.token}
+
+	for {
+		expr.Parts = append(expr.Parts, &StringLiteral{Token: p.token, Value: p.token.Literal})
+		if p.token.Type == token.STRING_END {
+			return expr
+		}
+
+		// Strings can span lines, so can their interpolations
+		p.nextToken()
+		p.skipNewlines()
+
+		value := p.parseExpression(LOWEST)
+		if value == nil {
+			return nil
+		}
+		expr.Parts = append(expr.Parts, value)
+
+		p.skipPeekNewlines()
+
+		if !p.isPeekToken(token.INTERPOLATION_END) {
+			p.errorf(token.INTERPOLATION_END, p.peekToken, "invalid syntax. Interpolation is missing closing }").
+				label(expr.Token.Pos, "string starts here")
+			return nil
+		}
+
+		// The string goes on with another STRING_PART or ends with STRING_END
+		p.nextToken()
+	}
+}
+
 func (p *Parser) parseBooleanLiteral() Expression {
 	return &BooleanLiteral{
 		Token: p.token,
@@ -253,20 +559,34 @@ func (p *Parser) parseIfExpression() Expression {
 	p.nextToken()
 
 	expr.Condition = p.parseExpression(LOWEST)
+	if expr.Condition == nil {
+		return nil
+	}
 
 	if !p.isPeekToken(token.LBRACE) {
-		log.Println("if expression missing opening {")
+		p.errorf(token.LBRACE, p.peekToken, "invalid syntax. If expression is missing opening {")
 		return nil
 	}
 
+	// Every branch runs in its own scope
+	p.openScope()
 	expr.Consequence = p.parseBlockStatement()
+	p.closeScope()
+	if expr.Consequence == nil {
+		return nil
+	}
 
 	if p.isPeekToken(token.ELSE) {
 		if !p.isPeekToken(token.LBRACE) {
-			log.Println("else block is missing opening {")
+			p.errorf(token.LBRACE, p.peekToken, "invalid syntax. Else block is missing opening {")
 			return nil
 		}
+		p.openScope()
 		expr.Otherwise = p.parseBlockStatement()
+		p.closeScope()
+		if expr.Otherwise == nil {
+			return nil
+		}
 	}
 
 	return expr
@@ -276,31 +596,186 @@ func (p *Parser) parseFunctionExpression() Expression {
 	expr := &FunctionLiteral{Token: p.token}
 
 	if !p.isPeekToken(token.LPAREN) {
-		log.Println("function is missing opening (")
+		p.errorf(token.LPAREN, p.peekToken, "invalid syntax. Function is missing opening (")
+		return nil
+	}
+
+	if !p.parseParameters(expr) || !p.parseFunctionBody(expr) {
+		return nil
+	}
+
+	return expr
+}
+
+func (p *Parser) parseFunctionDeclaration() Statement {
+	s := &FunctionDeclaration{Token: p.token}
+
+	p.nextToken()
+
+	s.Name = &Identifier{Token: p.token, Value: p.token.Literal}
+	s.Function = &FunctionLiteral{Token: s.Token}
+
+	if !p.assertNextToken(token.LPAREN) || !p.parseParameters(s.Function) {
+		return nil
+	}
+
+	// Declare the function before parsing the body, so calls to itself are
+	// checked too
+	p.declareFunction(s.Name.Value, s.Function)
+
+	if !p.parseFunctionBody(s.Function) || !p.assertEnd() {
 		return nil
 	}
 
+	return s
+}
+
+// parseParameters parses the parameter list and the optional return type of
+// a function. The current token is the opening (.
+func (p *Parser) parseParameters(fn *FunctionLiteral) bool {
 	for !p.isPeekToken(token.RPAREN) {
 		p.isPeekToken(token.KOMMA)
 
 		if !p.isPeekToken(token.IDENTIFIER) {
-			log.Println("function parameter found is not an identifier")
-			return nil
+			p.errorf(token.IDENTIFIER, p.peekToken, "invalid syntax. Function parameter is not an identifier")
+			return false
 		}
-		expr.Parameters = append(expr.Parameters, &Identifier{
+		fn.Parameters = append(fn.Parameters, &Identifier{
 			Token: p.token,
 			Value: p.token.Literal,
 		})
+
+		var paramType *Identifier
+		if p.isPeekToken(token.COLON) {
+			if !p.assertNextToken(token.IDENTIFIER) {
+				return false
+			}
+			paramType = &Identifier{Token: p.token, Value: p.token.Literal}
+		}
+		fn.ParameterTypes = append(fn.ParameterTypes, paramType)
 	}
 
+	if p.isPeekToken(token.IDENTIFIER) {
+		fn.ReturnType = &Identifier{Token: p.token, Value: p.token.Literal}
+	}
+
+	return true
+}
+
+func (p *Parser) parseFunctionBody(fn *FunctionLiteral) bool {
 	if !p.isPeekToken(token.LBRACE) {
-		log.Println("function body opening { missing")
+		p.errorf(token.LBRACE, p.peekToken, "invalid syntax. Function body is missing opening {")
+		return false
+	}
+
+	p.openScope()
+	for _, param := range fn.Parameters {
+		p.declare(param.Value, nil)
+	}
+
+	// Loops around the function can't be left from inside of it
+	loopDepth := p.loopDepth
+	p.loopDepth = 0
+
+	fn.Body = p.parseBlockStatement()
+
+	p.loopDepth = loopDepth
+	p.closeScope()
+
+	return fn.Body != nil
+}
+
+// parseEnumDeclaration parses enum Shape { Circle(r), Rect(w, h), Empty }.
+// Like in other lists, line breaks and a trailing comma are allowed.
+func (p *Parser) parseEnumDeclaration() Statement {
+	s := &EnumDeclaration{Token: p.token}
+
+	if !p.assertNextToken(token.IDENTIFIER) {
 		return nil
 	}
+	s.Name = &Identifier{Token: p.token, Value: p.token.Literal}
 
-	expr.Body = p.parseBlockStatement()
+	if !p.assertNextToken(token.LBRACE) {
+		return nil
+	}
+	open := p.token
 
-	return expr
+	declared := map[string]*EnumVariant{}
+	for {
+		p.skipPeekNewlines()
+
+		if p.peekToken.Type == token.RBRACE {
+			break
+		}
+
+		if !p.assertNextToken(token.IDENTIFIER) {
+			return nil
+		}
+		variant := &EnumVariant{Name: &Identifier{Token: p.token, Value: p.token.Literal}}
+
+		if previous, ok := declared[variant.Name.Value]; ok {
+			p.errorf("", p.token, "invalid syntax. Enum variant %s is declared twice", variant.Name.Value).
+				label(previous.Pos(), "previously declared here")
+			return nil
+		}
+		declared[variant.Name.Value] = variant
+
+		if p.isPeekToken(token.LPAREN) && !p.parseEnumFields(variant) {
+			return nil
+		}
+		s.Variants = append(s.Variants, variant)
+
+		p.skipPeekNewlines()
+
+		if !p.isPeekToken(token.KOMMA) {
+			break
+		}
+	}
+
+	if !p.isPeekToken(token.RBRACE) {
+		p.errorf(token.RBRACE, p.peekToken, "invalid syntax. Enum is missing closing }").
+			label(open.Pos, "{ opened here")
+		return nil
+	}
+
+	if len(s.Variants) == 0 {
+		p.errorf("", s.Name.Token, "invalid syntax. Enum %s has no variants", s.Name.Value)
+		return nil
+	}
+
+	p.declareEnum(s)
+
+	if !p.assertEnd() {
+		return nil
+	}
+
+	return s
+}
+
+// parseEnumFields parses the field names of a variant. The current token is
+// the opening (.
+func (p *Parser) parseEnumFields(variant *EnumVariant) bool {
+	for {
+		if !p.assertNextToken(token.IDENTIFIER) {
+			return false
+		}
+		field := &Identifier{Token: p.token, Value: p.token.Literal}
+
+		for _, other := range variant.Fields {
+			if other.Value == field.Value {
+				p.errorf("", p.token, "invalid syntax. Field %s of %s is declared twice", field.Value, variant.Name.Value).
+					label(other.Pos(), "previously declared here")
+				return false
+			}
+		}
+		variant.Fields = append(variant.Fields, field)
+
+		if !p.isPeekToken(token.KOMMA) {
+			break
+		}
+	}
+
+	return p.assertNextToken(token.RPAREN)
 }
 
 func (p *Parser) parseFunctionCall(left Expression) Expression {
@@ -309,40 +784,185 @@ func (p *Parser) parseFunctionCall(left Expression) Expression {
 		Function: left,
 	}
 
-	p.skipNewline()
+	args, ok := p.parseExpressionList(token.RPAREN, "Function call")
+	if !ok {
+		return nil
+	}
+	expr.Arguments = args
+	expr.Close = p.token
+
+	p.checkArity(expr)
 
-	if p.isPeekToken(token.RPAREN) {
-		return expr
+	return expr
+}
+
+func (p *Parser) parseArrayLiteral() Expression {
+	expr := &ArrayLiteral{Token: p.token}
+
+	elements, ok := p.parseExpressionList(token.RBRACKET, "Array literal")
+	if !ok {
+		return nil
 	}
+	expr.Elements = elements
+	expr.Close = p.token
+
+	return expr
+}
+
+// parseHashLiteral parses a literal like {name: "thorsten", 1: true}. Keys
+// that are plain identifiers are taken as strings. Any other expression can
+// be used as key, including a variable wrapped in parentheses.
+func (p *Parser) parseHashLiteral() Expression {
+	expr := &HashLiteral{Token: p.token}
 
 	for {
-		p.skipNewline()
+		p.skipPeekNewlines()
+
+		if p.peekToken.Type == token.RBRACE {
+			break
+		}
+
 		p.nextToken()
-		arg := p.parseExpression(LOWEST)
-		expr.Arguments = append(expr.Arguments, arg)
+
+		var key Expression
+		if p.token.Type == token.IDENTIFIER && p.peekToken.Type == token.COLON {
+			key = &StringLiteral{Token: p.token, Value: p.token.Literal}
+		} else {
+			key = p.parseExpression(LOWEST)
+			if key == nil {
+				return nil
+			}
+		}
+
+		if !p.assertNextToken(token.COLON) {
+			return nil
+		}
+
+		p.nextToken()
+
+		value := p.parseExpression(LOWEST)
+		if value == nil {
+			return nil
+		}
+
+		expr.Keys = append(expr.Keys, key)
+		expr.Values = append(expr.Values, value)
+
+		p.skipPeekNewlines()
 
 		if !p.isPeekToken(token.KOMMA) {
 			break
 		}
 	}
 
-	p.skipNewline()
+	if !p.isPeekToken(token.RBRACE) {
+		p.errorf(token.RBRACE, p.peekToken, "invalid syntax. Hash literal is missing closing }").
+			label(expr.Token.Pos, "{ opened here")
+		return nil
+	}
+	expr.Close = p.token
 
-	if !p.isPeekToken(token.RPAREN) {
-		log.Println("function call: closing ) missing")
+	return expr
+}
+
+func (p *Parser) parseIndexExpression(left Expression) Expression {
+	expr := &IndexExpression{
+		Token: p.token,
+		Left:  left,
+	}
+
+	p.nextToken()
+
+	expr.Index = p.parseExpression(LOWEST)
+	if expr.Index == nil {
+		return nil
+	}
+
+	if !p.isPeekToken(token.RBRACKET) {
+		p.errorf(token.RBRACKET, p.peekToken, "invalid syntax. Index expression is missing closing ]").
+			label(expr.Token.Pos, "[ opened here")
+		return nil
+	}
+	expr.Close = p.token
+
+	return expr
+}
+
+// parseMemberExpression parses the name after a dot, like Circle in
+// Shape.Circle.
+func (p *Parser) parseMemberExpression(left Expression) Expression {
+	expr := &MemberExpression{
+		Token: p.token,
+		Left:  left,
+	}
+
+	if !p.assertNextToken(token.IDENTIFIER) {
 		return nil
 	}
+	expr.Member = &Identifier{Token: p.token, Value: p.token.Literal}
 
 	return expr
 }
 
+// parseExpressionList parses comma separated expressions following the
+// opening bracket at the current token, up to the closing end token. Line
+// breaks and a trailing comma are allowed. The name of the construct is
+// used for the error message.
+func (p *Parser) parseExpressionList(end token.TokenType, construct string) ([]Expression, bool) {
+	open := p.token
+	list := []Expression{}
+
+	p.skipPeekNewlines()
+
+	if p.isPeekToken(end) {
+		return list, true
+	}
+
+	for {
+		p.skipPeekNewlines()
+
+		// Allow a trailing comma
+		if p.peekToken.Type == end {
+			break
+		}
+
+		p.nextToken()
+
+		expr := p.parseExpression(LOWEST)
+		if expr == nil {
+			return nil, false
+		}
+		list = append(list, expr)
+
+		p.skipPeekNewlines()
+
+		if !p.isPeekToken(token.KOMMA) {
+			break
+		}
+	}
+
+	if !p.isPeekToken(end) {
+		p.errorf(end, p.peekToken, "invalid syntax. %s is missing closing %s", construct, end).
+			label(open.Pos, open.Literal+" opened here")
+		return nil, false
+	}
+
+	return list, true
+}
+
 func (p *Parser) parseGroupedExpression() Expression {
+	lparen := p.token
+
 	p.nextToken()
 
 	expr := p.parseExpression(LOWEST)
+	if expr == nil {
+		return nil
+	}
 
 	if !p.isPeekToken(token.RPAREN) {
-		log.Println("opened ( is missing closing )")
+		p.errorf(token.RPAREN, p.peekToken, "invalid syntax. Opened ( is missing closing )").
+			label(lparen.Pos, "( opened here")
 		return nil
 	}
 
@@ -358,6 +978,9 @@ func (p *Parser) parsePrefixExpression() Expression {
 	p.nextToken()
 
 	prefixExp.Right = p.parseExpression(PREFIX)
+	if prefixExp.Right == nil {
+		return nil
+	}
 
 	return prefixExp
 }
@@ -374,29 +997,83 @@ func (p *Parser) parseInfixExpression(left Expression) Expression {
 		precedence = LOWEST
 	}
 
+	// ** is right associative: 2 ** 3 ** 2 is 2 ** (3 ** 2)
+	if p.token.Type == token.POWER {
+		precedence--
+	}
+
 	p.nextToken()
 
 	infixExp.Right = p.parseExpression(precedence)
+	if infixExp.Right == nil {
+		return nil
+	}
 
 	return infixExp
 }
 
-func (p *Parser) assertEnd() {
+// assertEnd reports whether the statement ends after the current token.
+func (p *Parser) assertEnd() bool {
 	if p.peekToken.Type != token.NEWLINE && p.peekToken.Type != token.EOF && p.peekToken.Type != token.RBRACE {
-		log.Panicf("invalid syntax. Expected end of statement but got %q", p.peekToken.Type)
+		p.errorf("", p.peekToken, "invalid syntax. Expected end of statement but got %q", p.peekToken.Type)
+		return false
 	}
-	p.nextToken()
+	return true
 }
 
-func (p *Parser) assertNextToken(t token.TokenType) {
+func (p *Parser) assertNextToken(t token.TokenType) bool {
 	if p.peekToken.Type != t {
-		log.Panicf("invalid syntax. Expected %q but got %q", t, p.peekToken.Type)
+		p.errorf(t, p.peekToken, "invalid syntax. Expected %q but got %q", t, p.peekToken.Type)
+		return false
 	}
 	p.nextToken()
+	return true
 }
 
-func (p *Parser) skipNewline() {
-	if p.peekToken.Type == token.NEWLINE {
+func (p *Parser) errorf(expected token.TokenType, found token.Token, message string, a ...interface{}) *Diagnostic {
+	d := &Diagnostic{
+		Message:  fmt.Sprintf(message, a...),
+		Expected: expected,
+		Found:    found,
+	}
+	p.errors = append(p.errors, d)
+	return d
+}
+
+// synchronize skips the tokens of a statement that failed to parse. It stops
+// at the next statement boundary, which is a NEWLINE or the } closing the
+// enclosing block. Blocks opened while skipping are skipped as a whole.
+func (p *Parser) synchronize() {
+	depth := 0
+
+	for p.token.Type != token.EOF {
+		switch p.token.Type {
+		case token.LBRACE:
+			depth++
+		case token.RBRACE:
+			if depth == 0 {
+				return
+			}
+			depth--
+		case token.NEWLINE:
+			if depth == 0 {
+				return
+			}
+		}
+		p.nextToken()
+	}
+}
+
+// skipNewlines skips NEWLINE tokens at the current position.
+func (p *Parser) skipNewlines() {
+	for p.token.Type == token.NEWLINE {
+		p.nextToken()
+	}
+}
+
+// skipPeekNewlines skips NEWLINE tokens following the current token.
+func (p *Parser) skipPeekNewlines() {
+	for p.peekToken.Type == token.NEWLINE {
 		p.nextToken()
 	}
 }
@@ -408,3 +1085,7 @@ func (p *Parser) isPeekToken(t token.TokenType) bool {
 	}
 	return ok
 }
+
+func isDigit(char byte) bool {
+	return char >= '0' && char <= '9'
+}
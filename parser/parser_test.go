@@ -25,6 +25,133 @@ func TestStrings(t *testing.T) {
 	}
 }
 
+func TestNodePositions(t *testing.T) {
+	input := `var foo = 1
+foo + 2`
+
+	l := lexer.New(input)
+	p := parser.New(l).ParseProgram()
+
+	expectPosition(t, p.Statements[0], 1, 1)
+	declare := p.Statements[0].(*parser.DeclareStatement)
+	expectPosition(t, declare.Name, 1, 5)
+	expectPosition(t, declare.Expression, 1, 11)
+	stmt := expectExpressionStatement(t, p.Statements[1])
+	expectPosition(t, stmt, 2, 1)
+	infix := stmt.Value.(*parser.InfixExpression)
+	expectPosition(t, infix, 2, 5)
+	expectPosition(t, infix.Right, 2, 7)
+}
+
+func expectPosition(t *testing.T, node parser.Node, line, column int) {
+	pos := node.Pos()
+	if pos.Line != line || pos.Column != column {
+		t.Fatalf("position of %q wrong. expected %d:%d got %d:%d", node.String(), line, column, pos.Line, pos.Column)
+	}
+}
+
+func TestArrayLiteral(t *testing.T) {
+	input := `[1, a, true]
+[]
+[
+	1,
+	2,
+]`
+
+	l := lexer.New(input)
+	p := parser.New(l)
+	program := p.ParseProgram()
+	expectErrors(t, p, 0)
+	expectStatements(t, program, 3)
+
+	array := expectArrayLiteral(t, expectExpressionStatement(t, program.Statements[0]).Value, 3)
+	expectLiteralExpression(t, array.Elements[0], 1)
+	expectLiteralExpression(t, array.Elements[1], "a")
+	expectLiteralExpression(t, array.Elements[2], true)
+
+	expectArrayLiteral(t, expectExpressionStatement(t, program.Statements[1]).Value, 0)
+
+	array = expectArrayLiteral(t, expectExpressionStatement(t, program.Statements[2]).Value, 2)
+	expectLiteralExpression(t, array.Elements[1], 2)
+}
+
+func expectArrayLiteral(t *testing.T, expr parser.Expression, length int) *parser.ArrayLiteral {
+	array, ok := expr.(*parser.ArrayLiteral)
+	if !ok {
+		t.Fatalf("expression is not an ArrayLiteral. got %T", expr)
+	}
+	if len(array.Elements) != length {
+		t.Fatalf("wrong number of elements. expected %d got %d", length, len(array.Elements))
+	}
+	return array
+}
+
+func TestHashLiteral(t *testing.T) {
+	input := `{name: "thorsten", "age": 28, 1 + 1: true, (key): 2}
+{}
+{
+	a: 1,
+	b: 2,
+}`
+
+	l := lexer.New(input)
+	p := parser.New(l)
+	program := p.ParseProgram()
+	expectErrors(t, p, 0)
+	expectStatements(t, program, 3)
+
+	hash := expectHashLiteral(t, expectExpressionStatement(t, program.Statements[0]).Value, 4)
+	expectStringLiteral(t, hash.Keys[0], "name")
+	expectStringLiteral(t, hash.Values[0], "thorsten")
+	expectStringLiteral(t, hash.Keys[1], "age")
+	expectLiteralExpression(t, hash.Values[1], 28)
+	expectInfixExpression(t, hash.Keys[2], 1, "+", 1)
+	expectLiteralExpression(t, hash.Values[2], true)
+	expectIdentifier(t, hash.Keys[3], "key")
+
+	expectHashLiteral(t, expectExpressionStatement(t, program.Statements[1]).Value, 0)
+
+	hash = expectHashLiteral(t, expectExpressionStatement(t, program.Statements[2]).Value, 2)
+	expectStringLiteral(t, hash.Keys[1], "b")
+	expectLiteralExpression(t, hash.Values[1], 2)
+}
+
+func expectHashLiteral(t *testing.T, expr parser.Expression, length int) *parser.HashLiteral {
+	hash, ok := expr.(*parser.HashLiteral)
+	if !ok {
+		t.Fatalf("expression is not a HashLiteral. got %T", expr)
+	}
+	if len(hash.Keys) != length || len(hash.Values) != length {
+		t.Fatalf("wrong number of pairs. expected %d got %d", length, len(hash.Keys))
+	}
+	return hash
+}
+
+func expectStringLiteral(t *testing.T, expr parser.Expression, expect string) {
+	str, ok := expr.(*parser.StringLiteral)
+	if !ok {
+		t.Fatalf("expression is not a StringLiteral. got %T", expr)
+	}
+	if str.Value != expect {
+		t.Fatalf("string literal wrong. expected %s got %s", expect, str.Value)
+	}
+}
+
+func TestIndexExpression(t *testing.T) {
+	input := "list[1 + 1]"
+
+	l := lexer.New(input)
+	p := parser.New(l).ParseProgram()
+
+	stmt := expectExpressionStatement(t, p.Statements[0])
+	index, ok := stmt.Value.(*parser.IndexExpression)
+	if !ok {
+		t.Fatalf("expression is not an IndexExpression. got %T", stmt.Value)
+	}
+	expectIdentifier(t, index.Left, "list")
+	expectInfixExpression(t, index.Index, 1, "+", 1)
+}
+
 func TestLineBreaks3(t *testing.T) {
 	input := `
 var foo = fn(x, func) { return func(x) }
@@ -142,6 +269,220 @@ func TestFunctionLiteral(t *testing.T) {
 	expectInfixExpression(t, returnStmt.Expression, "a", "+", "b")
 }
 
+func TestFunctionDeclaration(t *testing.T) {
+	input := `fn add(a: int, b: int) int {
+	return a + b
+}
+fn noop() {}`
+
+	p := parser.New(lexer.New(input))
+	program := p.ParseProgram()
+	expectErrors(t, p, 0)
+	expectStatements(t, program, 2)
+
+	decl, ok := program.Statements[0].(*parser.FunctionDeclaration)
+	if !ok {
+		t.Fatalf("statement is not a FunctionDeclaration. got %T", program.Statements[0])
+	}
+	expectIdentifier(t, decl.Name, "add")
+	expectIdentifier(t, decl.Function.Parameters[1], "b")
+	expectIdentifier(t, decl.Function.ParameterTypes[1], "int")
+	expectIdentifier(t, decl.Function.ReturnType, "int")
+	expectPosition(t, decl, 1, 1)
+
+	tests := []struct {
+		input  string
+		expect string
+	}{
+		{"fn add(a: int, b: int) int { a + b }", "fn add(a: int, b: int) int {(a + b)}"},
+		{"fn noop() {}", "fn noop(){}"},
+		{"var f = fn(a, b: int) { a }", "var f = fn(a, b: int){a}"},
+		{"fn add(a, b) { a + b }\nadd(1)", "wrong number of arguments for add. expected 2, got 1"},
+		{"fn add(a: ) {}", "invalid syntax. Expected \"IDENTIFIER\" but got \")\""},
+		{"fn add(a) {} 1", "invalid syntax. Expected end of statement but got \"INTEGER\""},
+	}
+
+	for _, test := range tests {
+		p := parser.New(lexer.New(test.input))
+		program := p.ParseProgram()
+		diagnostics := append(p.Errors(), p.Warnings()...)
+		if len(diagnostics) > 0 {
+			if diagnostics[0].Message != test.expect {
+				t.Fatalf("wrong error for %q\n\texpected: %q\n\tgot: %q", test.input, test.expect, diagnostics[0].Message)
+			}
+			continue
+		}
+		expectProgram(t, program, test.expect)
+
+		again := parser.New(lexer.New(program.String())).ParseProgram()
+		expectProgram(t, again, test.expect)
+	}
+}
+
+func TestEnumDeclaration(t *testing.T) {
+	input := `enum Shape {
+	Circle(r),
+	Rect(w, h),
+	Empty,
+}`
+
+	p := parser.New(lexer.New(input))
+	program := p.ParseProgram()
+	expectErrors(t, p, 0)
+	expectStatements(t, program, 1)
+
+	decl, ok := program.Statements[0].(*parser.EnumDeclaration)
+	if !ok {
+		t.Fatalf("statement is not an EnumDeclaration. got %T", program.Statements[0])
+	}
+	expectIdentifier(t, decl.Name, "Shape")
+	if len(decl.Variants) != 3 {
+		t.Fatalf("expected 3 variants. got %d", len(decl.Variants))
+	}
+	expectIdentifier(t, decl.Variants[1].Name, "Rect")
+	expectIdentifier(t, decl.Variants[1].Fields[1], "h")
+	if len(decl.Variants[2].Fields) != 0 {
+		t.Fatalf("expected Empty to have no fields. got %d", len(decl.Variants[2].Fields))
+	}
+	expectPosition(t, decl, 1, 1)
+
+	tests := []struct {
+		input  string
+		expect string
+	}{
+		{"enum Shape { Circle(r), Rect(w, h), Empty }", "enum Shape { Circle(r), Rect(w, h), Empty }"},
+		{"enum State { Idle, Running, }", "enum State { Idle, Running }"},
+		{"enum Shape { Circle(r) }\nShape.Circle(1, 2)", "wrong number of arguments for Shape.Circle. expected 1, got 2"},
+		{"Shape.Circle(1)", "(Shape.Circle)(1)"},
+		{"shape.r + 1", "((shape.r) + 1)"},
+		{"Shape.", "invalid syntax. Expected \"IDENTIFIER\" but got \"EOF\""},
+		{"enum State {}", "invalid syntax. Enum State has no variants"},
+		{"enum State { Idle, Idle }", "invalid syntax. Enum variant Idle is declared twice"},
+		{"enum Shape { Rect(w, w) }", "invalid syntax. Field w of Rect is declared twice"},
+		{"enum Shape { Circle() }", "invalid syntax. Expected \"IDENTIFIER\" but got \")\""},
+		{"enum State { Idle Running }", "invalid syntax. Enum is missing closing }"},
+		{"enum { Idle }", "invalid syntax. Expected \"IDENTIFIER\" but got \"{\""},
+	}
+
+	for _, test := range tests {
+		p := parser.New(lexer.New(test.input))
+		program := p.ParseProgram()
+		diagnostics := append(p.Errors(), p.Warnings()...)
+		if len(diagnostics) > 0 {
+			if diagnostics[0].Message != test.expect {
+				t.Fatalf("wrong error for %q\n\texpected: %q\n\tgot: %q", test.input, test.expect, diagnostics[0].Message)
+			}
+			continue
+		}
+		expectProgram(t, program, test.expect)
+
+		again := parser.New(lexer.New(program.String())).ParseProgram()
+		expectProgram(t, again, test.expect)
+	}
+}
+
+func TestIntegerLiteral(t *testing.T) {
+	tests := []struct {
+		input  string
+		expect int64
+	}{
+		{"42", 42},
+		{"0xff", 255},
+		{"0XFF", 255},
+		{"0o17", 15},
+		{"0b1010", 10},
+		{"1_000_000", 1000000},
+		{"0", 0},
+		{"0x_7fff_ffff_ffff_ffff", 9223372036854775807},
+	}
+
+	for _, test := range tests {
+		p := parser.New(lexer.New(test.input))
+		program := p.ParseProgram()
+		expectErrors(t, p, 0)
+
+		stmt := expectExpressionStatement(t, program.Statements[0])
+		literal, ok := stmt.Value.(*parser.IntegerLiteral)
+		if !ok {
+			t.Fatalf("expression is not an IntegerLiteral. got %T", stmt.Value)
+		}
+		if literal.Value != test.expect {
+			t.Fatalf("wrong value for %q. expected %d got %d", test.input, test.expect, literal.Value)
+		}
+		expectProgram(t, program, test.input)
+	}
+}
+
+func TestFloatLiteral(t *testing.T) {
+	tests := []struct {
+		input  string
+		expect float64
+	}{
+		{"1.5", 1.5},
+		{"0.001", 0.001},
+		{"1e3", 1000},
+		{"2.5e-1", 0.25},
+		{"1_000.5", 1000.5},
+	}
+
+	for _, test := range tests {
+		p := parser.New(lexer.New(test.input))
+		program := p.ParseProgram()
+		expectErrors(t, p, 0)
+
+		stmt := expectExpressionStatement(t, program.Statements[0])
+		literal, ok := stmt.Value.(*parser.FloatLiteral)
+		if !ok {
+			t.Fatalf("expression is not a FloatLiteral. got %T", stmt.Value)
+		}
+		if literal.Value != test.expect {
+			t.Fatalf("wrong value for %q. expected %v got %v", test.input, test.expect, literal.Value)
+		}
+		expectProgram(t, program, test.input)
+	}
+}
+
+func TestInterpolatedString(t *testing.T) {
+	input := `"hello ${name}, you are ${age + 1}"`
+
+	p := parser.New(lexer.New(input))
+	program := p.ParseProgram()
+	expectErrors(t, p, 0)
+
+	stmt := expectExpressionStatement(t, program.Statements[0])
+	str, ok := stmt.Value.(*parser.InterpolatedString)
+	if !ok {
+		t.Fatalf("expression is not an InterpolatedString. got %T", stmt.Value)
+	}
+	if len(str.Parts) != 5 {
+		t.Fatalf("wrong number of parts. expected 5 got %d", len(str.Parts))
+	}
+	expectStringLiteral(t, str.Parts[0], "hello ")
+	expectIdentifier(t, str.Parts[1], "name")
+	expectStringLiteral(t, str.Parts[2], ", you are ")
+	expectInfixExpression(t, str.Parts[3], "age", "+", 1)
+	expectStringLiteral(t, str.Parts[4], "")
+	expectProgram(t, program, `"hello ${name}, you are ${(age + 1)}"`)
+
+	tests := []struct {
+		input  string
+		expect string
+	}{
+		{`"${}"`, "invalid syntax. Unexpected \"INTERPOLATION_END\""},
+		{`"${1 2}"`, "invalid syntax. Interpolation is missing closing }"},
+		{`"${1`, "invalid syntax. Interpolation is missing closing }"},
+	}
+
+	for _, test := range tests {
+		p := parser.New(lexer.New(test.input))
+		p.ParseProgram()
+		errors := expectErrors(t, p, 1)
+		if errors[0].Message != test.expect {
+			t.Fatalf("wrong error for %q\n\texpected: %q\n\tgot: %q", test.input, test.expect, errors[0].Message)
+		}
+	}
+}
+
 func TestIfExpressions(t *testing.T) {
 	input := "if true == 1 { 1 } else { 2 }"
 
@@ -175,6 +516,19 @@ func TestOperatorPrecedence(t *testing.T) {
 		{"1 == 2 * 4 + !5 < 6 / true < 3", "(1 == ((((2 * 4) + (!5)) < (6 / true)) < 3))"},
 		{"(1 + 2) * 3", "((1 + 2) * 3)"},
 		{"1 + (2 + 3) + 4", "((1 + (2 + 3)) + 4)"},
+		{"a * [1, 2][b + 1]", "(a * ([1, 2][(b + 1)]))"},
+		{"foo(a)[0] + -b[1]", "((foo(a)[0]) + (-(b[1])))"},
+		{"a || b && c", "(a || (b && c))"},
+		{"a && b || c && d", "((a && b) || (c && d))"},
+		{"a < 1 && b == 2 || !c", "(((a < 1) && (b == 2)) || (!c))"},
+		{"a && b && c", "((a && b) && c)"},
+		{"a <= b == c >= d", "((a <= b) == (c >= d))"},
+		{"a + b % c * d", "(a + ((b % c) * d))"},
+		{"2 ** 3 ** 2", "(2 ** (3 ** 2))"},
+		{"-2 ** 2", "(-(2 ** 2))"},
+		{"a * b ** c", "(a * (b ** c))"},
+		{"2 ** -1", "(2 ** (-1))"},
+		{"a ** b[0]", "(a ** (b[0]))"},
 	}
 
 	for _, test := range tests {
@@ -321,14 +675,163 @@ func TestAssertNextToken(t *testing.T) {
 
 	l := lexer.New(input)
 	p := parser.New(l)
+	p.ParseProgram()
+
+	errors := expectErrors(t, p, 1)
+	if errors[0].Message != expect {
+		t.Fatalf("wrong error message\n\texpected: %q\n\tgot: %q", expect, errors[0].Message)
+	}
+	if errors[0].Expected != token.ASSIGN {
+		t.Fatalf("wrong expected token. expected %q got %q", token.ASSIGN, errors[0].Expected)
+	}
+	if errors[0].Found.Type != token.INTEGER || errors[0].Pos().Column != 7 {
+		t.Fatalf("wrong found token. got %q at %s", errors[0].Found.Type, errors[0].Pos())
+	}
+}
 
-	defer func() {
-		if r := recover(); r != expect {
-			t.Fatalf("did not panic as expected\n\texpected: %q\n\tgot: %q", expect, r)
+func TestSyntaxErrors(t *testing.T) {
+	tests := []struct {
+		input  string
+		expect string
+	}{
+		{"var = 5", "invalid syntax. Expected \"IDENTIFIER\" but got \"=\""},
+		{"var x = 5 6", "invalid syntax. Expected end of statement but got \"INTEGER\""},
+		{"1 2", "invalid syntax. Expected end of statement but got \"INTEGER\""},
+		{"foo() bar()", "invalid syntax. Expected end of statement but got \"IDENTIFIER\""},
+		{"1 +", "invalid syntax. Unexpected \"EOF\""},
+		{"if true 1", "invalid syntax. If expression is missing opening {"},
+		{"if true { 1", "invalid syntax. Block is missing closing }"},
+		{"fn(x { x }", "invalid syntax. Function parameter is not an identifier"},
+		{"foo(1, 2", "invalid syntax. Function call is missing closing )"},
+		{"(1 + 2", "invalid syntax. Opened ( is missing closing )"},
+		{"[1, 2", "invalid syntax. Array literal is missing closing ]"},
+		{"a[1", "invalid syntax. Index expression is missing closing ]"},
+		{"{a: 1", "invalid syntax. Hash literal is missing closing }"},
+		{"{a 1}", "invalid syntax. Expected \":\" but got \"INTEGER\""},
+		{"/* a /* b */", "invalid syntax. Block comment is missing closing */"},
+		{"99999999999999999999", "invalid syntax. Integer literal 99999999999999999999 does not fit into 64 bits"},
+		{"0x8000000000000000", "invalid syntax. Integer literal 0x8000000000000000 does not fit into 64 bits"},
+		{"1__0.5", "invalid syntax. Invalid float literal 1__0.5"},
+		{"1e400", "invalid syntax. Float literal 1e400 does not fit into 64 bits"},
+		{"0b102", "invalid syntax. Invalid integer literal 0b102"},
+		{"0x", "invalid syntax. Invalid integer literal 0x"},
+		{"1__000", "invalid syntax. Invalid integer literal 1__000"},
+		{"010", "invalid syntax. Integer literal 010 has a leading zero. Octal numbers are written like 0o17"},
+		{"0_1", "invalid syntax. Integer literal 0_1 has a leading zero. Octal numbers are written like 0o17"},
+	}
+
+	for _, test := range tests {
+		l := lexer.New(test.input)
+		p := parser.New(l)
+		program := p.ParseProgram()
+
+		errors := expectErrors(t, p, 1)
+		if errors[0].Message != test.expect {
+			t.Fatalf("wrong error message for %q\n\texpected: %q\n\tgot: %q", test.input, test.expect, errors[0].Message)
 		}
-	}()
+		expectStatements(t, program, 0)
+	}
+}
 
-	p.ParseProgram()
+func TestLexerErrors(t *testing.T) {
+	input := `x := "a\qb"
+y := 1
+z := "abc`
+
+	p := parser.New(lexer.New(input))
+	program := p.ParseProgram()
+
+	errors := expectErrors(t, p, 2)
+	if errors[0].Error() != "1:8: invalid syntax. Unknown escape sequence \\q" {
+		t.Fatalf("wrong first error. got %q", errors[0].Error())
+	}
+	if errors[1].Error() != "3:6: invalid syntax. String is missing closing \"" {
+		t.Fatalf("wrong second error. got %q", errors[1].Error())
+	}
+	expectStatements(t, program, 3)
+}
+
+func TestErrorRecovery(t *testing.T) {
+	input := `var x 5
+var y = 1
+foo(1, 2
+var foo = fn(a) {
+	var = a
+	if a 1 {
+		return 2
+	}
+	return a
+}
+}
+y + 1`
+
+	l := lexer.New(input)
+	p := parser.New(l)
+	program := p.ParseProgram()
+
+	errors := expectErrors(t, p, 5)
+	expectErrorAt := func(i, line int, message string) {
+		if errors[i].Pos().Line != line || errors[i].Message != message {
+			t.Fatalf("wrong error %d\n\texpected: %d: %s\n\tgot: %d: %s", i, line, message, errors[i].Pos().Line, errors[i].Message)
+		}
+	}
+	expectErrorAt(0, 1, "invalid syntax. Expected \"=\" but got \"INTEGER\"")
+	expectErrorAt(1, 4, "invalid syntax. Function call is missing closing )")
+	expectErrorAt(2, 5, "invalid syntax. Expected \"IDENTIFIER\" but got \"=\"")
+	expectErrorAt(3, 6, "invalid syntax. If expression is missing opening {")
+	expectErrorAt(4, 11, "invalid syntax. Unexpected \"}\"")
+
+	expectProgram(t, program, "var y = 1var foo = fn(a){return a}(y + 1)")
+}
+
+func TestArityWarnings(t *testing.T) {
+	tests := []struct {
+		input  string
+		expect []string
+	}{
+		{"fn(a) { a }(1, 2)", []string{"wrong number of arguments for <anonymous>. expected 1, got 2"}},
+		{"var add = fn(a, b) { a + b }\nadd(1)", []string{"wrong number of arguments for add. expected 2, got 1"}},
+		{"var f = fn() { 1 }\nvar g = fn(f) { f(1) }\nf()", []string{}},
+		{"var f = fn() { 1 }\nvar f = 1\nf(1)", []string{}},
+		{"var f = fn() { var f = fn(x) { x }\nf() }", []string{"wrong number of arguments for f. expected 1, got 0"}},
+		{"foo(1, 2)", []string{}},
+		{"f := fn(a) { a }\nif true { f := fn(a, b) { a + b } }\nf(1)", []string{}},
+		{"f := fn(a) { a }\nif true {} else { fn f(a, b) { a } }\nf(1)", []string{}},
+		{"f := fn(a) { a }\nif true { f(1, 2) }", []string{"wrong number of arguments for f. expected 1, got 2"}},
+		{"f := fn(a) { a }\nwhile false { f := fn(a, b) { a } }\nf(1)", []string{}},
+		{"f := fn(a) { a }\nif true {\nf(1, 2)\nfn f(a, b) { a + b }\n}", []string{}},
+		{"f := fn(a) { a }\nfn g() {\nif true { f(1, 2) }\nfn f(a, b) { a + b }\n}", []string{}},
+		{"g(1, 2)\nfn g(a) { a }", []string{"wrong number of arguments for g. expected 1, got 2"}},
+		{"if true { f(1, 2) }\nif true { fn f(a) { a } }", []string{}},
+		{"enum Shape { Circle(r) }\nShape.Circle(1)", []string{}},
+		{"enum Opt { None, Some(v) }\nenum Res { None, Err(e, f) }\nRes.Err(1)\nOpt.Some(1)", []string{"wrong number of arguments for Res.Err. expected 2, got 1"}},
+		{"enum Shape { Circle(r) }\nShape := 1\nShape.Circle(1, 2)", []string{}},
+		{"f := fn(a) { a }\nfor x in [1] { f := fn(a, b) { a } }\nf(1)", []string{}},
+	}
+
+	for _, test := range tests {
+		p := parser.New(lexer.New(test.input))
+		p.ParseProgram()
+		expectErrors(t, p, 0)
+
+		warnings := p.Warnings()
+		if len(warnings) != len(test.expect) {
+			t.Fatalf("wrong number of warnings for %q. expected %d got %d: %v", test.input, len(test.expect), len(warnings), warnings)
+		}
+		for i, warning := range warnings {
+			if warning.Message != test.expect[i] {
+				t.Fatalf("wrong warning\n\texpected: %q\n\tgot: %q", test.expect[i], warning.Message)
+			}
+		}
+	}
+}
+
+func expectErrors(t *testing.T, p *parser.Parser, expect int) []*parser.Diagnostic {
+	errors := p.Errors()
+	if len(errors) != expect {
+		t.Fatalf("wrong number of errors. expected %d got %d: %v", expect, len(errors), errors)
+	}
+	return errors
 }
 
 func TestDeclareStatement(t *testing.T) {
@@ -359,6 +862,136 @@ var foo = true`
 	}
 }
 
+func TestShortDeclareStatement(t *testing.T) {
+	input := `x := 5
+y := x
+add := fn(a, b) { a + b }`
+
+	l := lexer.New(input)
+	p := parser.New(l)
+	program := p.ParseProgram()
+	expectErrors(t, p, 0)
+
+	expect := []struct {
+		identifier string
+		value      interface{}
+	}{
+		{identifier: "x", value: 5},
+		{identifier: "y", value: "x"},
+	}
+
+	expectStatements(t, program, 3)
+
+	for i, test := range expect {
+		statement := program.Statements[i].(*parser.DeclareStatement)
+		if statement.Name.String() != test.identifier {
+			t.Fatalf("test failed: identifier wrong.\n\texpected %q\n\tgot %q", test.identifier, statement.Name.String())
+		}
+		if statement.Token.Type != token.DECLARE {
+			t.Fatalf("test failed: token wrong.\n\texpected %q\n\tgot %q", token.DECLARE, statement.Token.Type)
+		}
+		expectLiteralExpression(t, statement.Expression, test.value)
+	}
+
+	tests := []struct {
+		input  string
+		expect string
+	}{
+		{"x := 5", "x := 5"},
+		{"var x = 5", "var x = 5"},
+		{"add := fn(a, b) { a + b }", "add := fn(a, b){(a + b)}"},
+		{"x := y := 1", "invalid syntax. Expected end of statement but got \":=\""},
+	}
+
+	for _, test := range tests {
+		p := parser.New(lexer.New(test.input))
+		program := p.ParseProgram()
+		if errors := p.Errors(); len(errors) > 0 {
+			if errors[0].Message != test.expect {
+				t.Fatalf("wrong error for %q\n\texpected: %q\n\tgot: %q", test.input, test.expect, errors[0].Message)
+			}
+			continue
+		}
+		expectProgram(t, program, test.expect)
+
+		// The printed program has to parse to the same program again
+		again := parser.New(lexer.New(program.String())).ParseProgram()
+		expectProgram(t, again, test.expect)
+	}
+}
+
+func TestAssignStatement(t *testing.T) {
+	tests := []struct {
+		input  string
+		expect string
+	}{
+		{"x = 5", "x = 5"},
+		{"x += y * 2", "x += (y * 2)"},
+		{"x -= 1", "x -= 1"},
+		{"x *= 1", "x *= 1"},
+		{"x /= 1", "x /= 1"},
+		{"x = y = 1", "invalid syntax. Expected end of statement but got \"=\""},
+		{"x =", "invalid syntax. Unexpected \"EOF\""},
+	}
+
+	for _, test := range tests {
+		p := parser.New(lexer.New(test.input))
+		program := p.ParseProgram()
+		if errors := p.Errors(); len(errors) > 0 {
+			if errors[0].Message != test.expect {
+				t.Fatalf("wrong error for %q\n\texpected: %q\n\tgot: %q", test.input, test.expect, errors[0].Message)
+			}
+			continue
+		}
+		expectStatements(t, program, 1)
+		if _, ok := program.Statements[0].(*parser.AssignStatement); !ok {
+			t.Fatalf("statement is not an AssignStatement. got %T", program.Statements[0])
+		}
+		expectProgram(t, program, test.expect)
+	}
+
+	// The function bound to f is not known after the assignment
+	input := `f := fn(a) { a }
+	f = fn(a, b) { a + b }
+	f(1, 2)`
+	p := parser.New(lexer.New(input))
+	p.ParseProgram()
+	expectErrors(t, p, 0)
+	if warnings := p.Warnings(); len(warnings) != 0 {
+		t.Fatalf("expected no warnings. got %v", warnings)
+	}
+}
+
+func TestLoops(t *testing.T) {
+	tests := []struct {
+		input  string
+		expect string
+	}{
+		{"while x < 10 { x += 1 }", "while (x < 10) { x += 1 }"},
+		{"for x in [1, 2] { print(x) }", "for x in [1, 2] { print(x) }"},
+		{"while true {\n\tif x { break }\n\tcontinue\n}", "while true { if x { break } continue }"},
+		{"for x in y {\n\tfn() { break }\n}", "invalid syntax. break is only allowed inside of a loop"},
+		{"continue", "invalid syntax. continue is only allowed inside of a loop"},
+		{"while true { break 1 }", "invalid syntax. Expected end of statement but got \"INTEGER\""},
+		{"while true 1", "invalid syntax. While loop is missing opening {"},
+		{"for x y {}", "invalid syntax. Expected \"IN\" but got \"IDENTIFIER\""},
+		{"for x in y 1", "invalid syntax. For loop is missing opening {"},
+	}
+
+	for _, test := range tests {
+		p := parser.New(lexer.New(test.input))
+		program := p.ParseProgram()
+		if errors := p.Errors(); len(errors) > 0 {
+			if errors[0].Message != test.expect {
+				t.Fatalf("wrong error for %q\n\texpected: %q\n\tgot: %q", test.input, test.expect, errors[0].Message)
+			}
+			continue
+		}
+		expectStatements(t, program, 1)
+		expectProgram(t, program, test.expect)
+	}
+}
+
 func expectProgram(t *testing.T, p *parser.Program, expect string) {
 	actual := p.String()
 	if actual != expect {
@@ -445,6 +1078,6 @@ func expectIntegerLiteral(t *testing.T, exp parser.Expression, expect int64) {
 
 func expectStatements(t *testing.T, p *parser.Program, expect int) {
 	if len(p.Statements) != expect {
-		t.Fatalf("Wrong number of statements.\n\tExpected: %d\n\tGot: %d", 1, len(p.Statements))
+		t.Fatalf("Wrong number of statements.\n\tExpected: %d\n\tGot: %d", expect, len(p.Statements))
 	}
 }
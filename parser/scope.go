@@ -0,0 +1,185 @@
+package parser
+
+import (
+	"fmt"
+	"sort"
+	"unicode/utf8"
+
+	"github.com/maiksch/best-lang/token"
+)
+
+// The parser keeps track of variables that are bound directly to a function
+// literal. Calls to them can be checked for the right number of arguments
+// before the program runs. Everything else is left to the evaluator.
+//
+// Named functions are hoisted: inside their block they can be called before
+// their declaration. So a call can only be checked once it is known which
+// declaration its name ends up at. Until then the check waits in the scope
+// the call was made in, and moves to the outer scope when that one closes.
+
+// scope maps variable names to the function literal they are bound to. The
+// literal is nil if the value is not known ahead of time.
+type scope struct {
+	functions map[string]*FunctionLiteral
+	// enums holds the enums declared in the scope, so calls of their
+	// constructors can be checked too.
+	enums  map[string]*EnumDeclaration
+	checks []*arityCheck
+}
+
+// arityCheck is a call waiting to be checked. level is the index of the scope
+// the name was found in, or -1 if it is not declared yet.
+type arityCheck struct {
+	call  *FunctionCall
+	name  string
+	fn    *FunctionLiteral
+	level int
+}
+
+func (p *Parser) openScope() {
+	p.scopes = append(p.scopes, &scope{
+		functions: map[string]*FunctionLiteral{},
+		enums:     map[string]*EnumDeclaration{},
+	})
+}
+
+func (p *Parser) closeScope() {
+	closed := p.scopes[len(p.scopes)-1]
+	p.scopes = p.scopes[:len(p.scopes)-1]
+
+	outer := p.scopes[len(p.scopes)-1]
+	outer.checks = append(outer.checks, closed.checks...)
+}
+
+// declare records a variable in the innermost scope.
+func (p *Parser) declare(name string, value Expression) {
+	fn, _ := value.(*FunctionLiteral)
+	current := p.scopes[len(p.scopes)-1]
+	current.functions[name] = fn
+	delete(current.enums, name)
+}
+
+// declareEnum records an enum in the innermost scope.
+func (p *Parser) declareEnum(decl *EnumDeclaration) {
+	p.declare(decl.Name.Value, nil)
+	p.scopes[len(p.scopes)-1].enums[decl.Name.Value] = decl
+}
+
+// declareFunction records a named function. Calls in the same block that were
+// made before the declaration and found the name further out call this
+// function instead.
+func (p *Parser) declareFunction(name string, fn *FunctionLiteral) {
+	level := len(p.scopes) - 1
+	p.declare(name, fn)
+
+	for _, check := range p.scopes[level].checks {
+		if check.name == name && check.level < level {
+			check.fn = fn
+			check.level = level
+		}
+	}
+}
+
+// assign forgets the function literal a variable is bound to. Assignments can
+// happen conditionally, so the value is not known ahead of time anymore.
+func (p *Parser) assign(name string) {
+	for i := len(p.scopes) - 1; i >= 0; i-- {
+		if _, ok := p.scopes[i].functions[name]; ok {
+			p.scopes[i].functions[name] = nil
+			return
+		}
+	}
+}
+
+// resolve returns the function literal a name is bound to and the index of
+// the scope it was found in, or -1 if the name is not declared.
+func (p *Parser) resolve(name string) (*FunctionLiteral, int) {
+	for i := len(p.scopes) - 1; i >= 0; i-- {
+		if fn, ok := p.scopes[i].functions[name]; ok {
+			return fn, i
+		}
+	}
+	return nil, -1
+}
+
+// resolveEnum returns the enum a name is bound to, or nil if it is not bound
+// to an enum.
+func (p *Parser) resolveEnum(name string) *EnumDeclaration {
+	for i := len(p.scopes) - 1; i >= 0; i-- {
+		if decl, ok := p.scopes[i].enums[name]; ok {
+			return decl
+		}
+		if _, ok := p.scopes[i].functions[name]; ok {
+			return nil
+		}
+	}
+	return nil
+}
+
+// checkArity checks calls of function literals and enum constructors right
+// away. Calls of named functions are checked by finishArityChecks.
+func (p *Parser) checkArity(call *FunctionCall) {
+	switch callee := call.Function.(type) {
+	case *FunctionLiteral:
+		p.warnArity(call, "<anonymous>", callee)
+	case *MemberExpression:
+		enum, ok := callee.Left.(*Identifier)
+		if !ok {
+			return
+		}
+		decl := p.resolveEnum(enum.Value)
+		if decl == nil {
+			return
+		}
+		for _, variant := range decl.Variants {
+			if variant.Name.Value == callee.Member.Value && len(variant.Fields) > 0 {
+				constructor := &FunctionLiteral{Token: variant.Name.Token, Parameters: variant.Fields}
+				p.warnArity(call, decl.Name.Value+"."+variant.Name.Value, constructor)
+			}
+		}
+	case *Identifier:
+		fn, level := p.resolve(callee.Value)
+		current := p.scopes[len(p.scopes)-1]
+		current.checks = append(current.checks, &arityCheck{call: call, name: callee.Value, fn: fn, level: level})
+	}
+}
+
+// finishArityChecks checks the calls still waiting once the whole program is
+// parsed. Warnings are sorted by position.
+func (p *Parser) finishArityChecks() {
+	program := p.scopes[0]
+	for _, check := range program.checks {
+		if check.fn != nil {
+			p.warnArity(check.call, check.name, check.fn)
+		}
+	}
+	program.checks = nil
+
+	sort.SliceStable(p.warnings, func(i, j int) bool {
+		return p.warnings[i].Found.Pos.Offset < p.warnings[j].Found.Pos.Offset
+	})
+}
+
+// warnArity warns about a call whose number of arguments can't match the
+// called function. The warning points at the callee, which name spells out.
+func (p *Parser) warnArity(call *FunctionCall, name string, fn *FunctionLiteral) {
+	if len(fn.Parameters) == len(call.Arguments) {
+		return
+	}
+
+	found := token.Token{Type: token.IDENTIFIER, Literal: name, Pos: call.Pos()}
+	if _, ok := call.Function.(*FunctionLiteral); ok {
+		found = fn.Token
+	}
+
+	p.warnings = append(p.warnings, &Diagnostic{
+		Message: fmt.Sprintf("wrong number of arguments for %s. expected %d, got %d",
+			name, len(fn.Parameters), len(call.Arguments)),
+		Found: found,
+		Labels: []Label{{
+			Pos:     fn.Pos(),
+			Length:  utf8.RuneCountInString(fn.Token.Literal),
+			Message: "declared here",
+		}},
+	})
+}
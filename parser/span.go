@@ -0,0 +1,53 @@
+package parser
+
+import (
+	"unicode/utf8"
+
+	"github.com/maiksch/best-lang/token"
+)
+
+// End returns the position right after the last token of a node. Together
+// with Pos it is the span of source code the node was parsed from. Closing
+// brackets belong to the span, parentheses around an expression do not.
+func End(node Node) token.Position {
+	tok := lastToken(node)
+
+	length := utf8.RuneCountInString(tok.Literal)
+	switch tok.Type {
+	case token.STRING:
+		// The literal has no quotes and escape sequences are resolved, so
+		// the length is only exact for plain strings
+		length += 2
+	case token.STRING_END:
+		length++
+	}
+
+	end := tok.Pos
+	end.Offset += len(tok.Literal)
+	end.Column += length
+	return end
+}
+
+func lastToken(node Node) token.Token {
+	switch node := node.(type) {
+	case *StringLiteral:
+		return node.Token
+	case *InterpolatedString:
+		return lastToken(node.Parts[len(node.Parts)-1])
+	case *PrefixExpression:
+		return lastToken(node.Right)
+	case *InfixExpression:
+		return lastToken(node.Right)
+	case *FunctionCall:
+		return node.Close
+	case *ArrayLiteral:
+		return node.Close
+	case *HashLiteral:
+		return node.Close
+	case *IndexExpression:
+		return node.Close
+	case *MemberExpression:
+		return node.Member.Token
+	}
+	return token.Token{Literal: node.TokenLiteral(), Pos: node.Pos()}
+}
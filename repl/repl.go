@@ -4,7 +4,9 @@ import (
 	"bufio"
 	"fmt"
 	"io"
+	"os"
 
+	"github.com/maiksch/best-lang/diagnostic"
 	"github.com/maiksch/best-lang/evaluator"
 	"github.com/maiksch/best-lang/lexer"
 	"github.com/maiksch/best-lang/parser"
@@ -12,26 +14,56 @@ import (
 
 const PROMPT = ">> "
 
-func Start(r io.Reader, w io.Writer) {
+// Start reads and evaluates lines until the input ends or the program calls
+// the exit builtin. It returns the exit code the program asked for, or 0.
+func Start(r io.Reader, w io.Writer) int {
 	scanner := bufio.NewScanner(r)
 	env := evaluator.NewEnvrionment()
 
-	for {
+	color := false
+	if f, ok := w.(*os.File); ok {
+		color = diagnostic.UseColor(f)
+	}
+	renderer := diagnostic.NewRenderer(color)
+
+	for n := 1; ; n++ {
 		fmt.Fprintf(w, "%s", PROMPT)
 
 		scanned := scanner.Scan()
 		if !scanned {
-			return
+			return 0
 		}
 
+		// Every input gets its own name, so errors in functions declared by
+		// an earlier input can still show the right source line.
+		filename := fmt.Sprintf("<input %d>", n)
 		line := scanner.Text()
-		lexer := lexer.New(line)
+		renderer.AddSource(filename, line)
+
+		lexer := lexer.NewFile(filename, line)
 		parser := parser.New(lexer)
 
 		ast := parser.ParseProgram()
+		for _, warning := range parser.Warnings() {
+			renderer.Render(w, diagnostic.FromParseWarning(warning))
+		}
+		if errors := parser.Errors(); len(errors) > 0 {
+			for _, err := range errors {
+				renderer.Render(w, diagnostic.FromParseError(err))
+			}
+			continue
+		}
 
 		result := evaluator.Eval(ast, env)
 
+		switch result := result.(type) {
+		case *evaluator.Error:
+			renderer.Render(w, diagnostic.FromRuntimeError(result))
+			continue
+		case *evaluator.Exit:
+			return int(result.Code)
+		}
+
 		io.WriteString(w, result.Inspect())
 		io.WriteString(w, "\n")
 	}
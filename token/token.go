@@ -1,5 +1,7 @@
 package token
 
+import "fmt"
+
 type TokenType string
 
 const (
@@ -7,27 +9,54 @@ const (
 	EOF        = "EOF"
 	IDENTIFIER = "IDENTIFIER"
 	INTEGER    = "INTEGER"
+	FLOAT      = "FLOAT"
 	STRING     = "STRING"
 
+	// Interpolated strings like "a ${x} b" are split into STRING_PART "a ",
+	// the tokens of the expression x, INTERPOLATION_END and STRING_END " b".
+	// A string with several expressions has a STRING_PART before each.
+	STRING_PART       = "STRING_PART"
+	STRING_END        = "STRING_END"
+	INTERPOLATION_END = "INTERPOLATION_END"
+
+	COMMENT = "COMMENT"
+
 	// Symbols
-	ASSIGN  = "="
-	LPAREN  = "("
-	RPAREN  = ")"
-	LBRACE  = "{"
-	RBRACE  = "}"
-	KOMMA   = ","
-	PLUS    = "+"
-	MINUS   = "-"
-	SLASH   = "/"
-	STAR    = "*"
-	LT      = "<"
-	GT      = ">"
-	BANG    = "!"
-	NEWLINE = "NEWLINE"
+	ASSIGN   = "="
+	LPAREN   = "("
+	RPAREN   = ")"
+	LBRACE   = "{"
+	RBRACE   = "}"
+	LBRACKET = "["
+	RBRACKET = "]"
+	KOMMA    = ","
+	COLON    = ":"
+	DOT      = "."
+	PLUS     = "+"
+	MINUS    = "-"
+	SLASH    = "/"
+	STAR     = "*"
+	PERCENT  = "%"
+	LT       = "<"
+	GT       = ">"
+	BANG     = "!"
+	NEWLINE  = "NEWLINE"
 
 	// Two Symbol Tokens
 	EQUAL     = "=="
 	NOT_EQUAL = "!="
+	DECLARE   = ":="
+	AND       = "&&"
+	OR        = "||"
+	LT_EQUAL  = "<="
+	GT_EQUAL  = ">="
+	POWER     = "**"
+
+	// Compound assignments
+	PLUS_ASSIGN  = "+="
+	MINUS_ASSIGN = "-="
+	STAR_ASSIGN  = "*="
+	SLASH_ASSIGN = "/="
 
 	// Keywords
 	VARIABLE = "VAR"
@@ -37,24 +66,65 @@ const (
 	IF       = "IF"
 	ELSE     = "ELSE"
 	RETURN   = "RETURN"
+	WHILE    = "WHILE"
+	FOR      = "FOR"
+	IN       = "IN"
+	BREAK    = "BREAK"
+	CONTINUE = "CONTINUE"
+	ENUM     = "ENUM"
 )
 
 type Token struct {
 	Type    TokenType
 	Literal string
+	Pos     Position
+}
+
+// Position describes where a token starts in the source. Line and Column
+// are 1-based, Offset is the 0-based byte offset into the input. Column
+// counts characters, not bytes.
+type Position struct {
+	Filename string
+	Offset   int
+	Line     int
+	Column   int
+}
+
+// IsValid reports whether the position has been set by the lexer.
+func (p Position) IsValid() bool {
+	return p.Line > 0
+}
+
+func (p Position) String() string {
+	s := p.Filename
+	if p.IsValid() {
+		if s != "" {
+			s += ":"
+		}
+		s += fmt.Sprintf("%d:%d", p.Line, p.Column)
+	}
+	if s == "" {
+		s = "-"
+	}
+	return s
 }
 
-var Symbols = map[byte]TokenType{
+var Symbols = map[rune]TokenType{
 	'=':  ASSIGN,
 	'(':  LPAREN,
 	')':  RPAREN,
 	'{':  LBRACE,
 	'}':  RBRACE,
+	'[':  LBRACKET,
+	']':  RBRACKET,
 	',':  KOMMA,
+	':':  COLON,
+	'.':  DOT,
 	'+':  PLUS,
 	'-':  MINUS,
 	'/':  SLASH,
 	'*':  STAR,
+	'%':  PERCENT,
 	'<':  LT,
 	'>':  GT,
 	'!':  BANG,
@@ -62,13 +132,19 @@ var Symbols = map[byte]TokenType{
 }
 
 var keywords = map[string]TokenType{
-	"var":    VARIABLE,
-	"fn":     FUNCTION,
-	"true":   TRUE,
-	"false":  FALSE,
-	"if":     IF,
-	"else":   ELSE,
-	"return": RETURN,
+	"var":      VARIABLE,
+	"fn":       FUNCTION,
+	"true":     TRUE,
+	"false":    FALSE,
+	"if":       IF,
+	"else":     ELSE,
+	"return":   RETURN,
+	"while":    WHILE,
+	"for":      FOR,
+	"in":       IN,
+	"break":    BREAK,
+	"continue": CONTINUE,
+	"enum":     ENUM,
 }
 
 func GetWordTokenType(word string) TokenType {